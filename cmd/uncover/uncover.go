@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"os"
+	"os/signal"
+	"syscall"
 
 	// Attempts to increase the OS file descriptors - Fail silently
 	_ "github.com/projectdiscovery/fdmax/autofdmax"
@@ -10,6 +13,22 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "db" {
+		// the db command searches the results recorded with -db
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		if err := runner.RunDB(runner.ParseDBOptions()); err != nil {
+			gologger.Fatal().Msgf("Could not search database: %s\n", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		// the migrate command rewrites the provider config to the latest version
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		if err := runner.RunMigrate(runner.ParseMigrateOptions()); err != nil {
+			gologger.Fatal().Msgf("Could not migrate provider config: %s\n", err)
+		}
+		return
+	}
 	// Parse the command line flags and read config files
 	options := runner.ParseOptions()
 
@@ -18,7 +37,10 @@ func main() {
 		gologger.Fatal().Msgf("Could not create runner: %s\n", err)
 	}
 
-	err = newRunner.Run(context.Background())
+	// an interrupted run is closed so that its progress is saved for -resume
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = newRunner.Run(ctx)
 	if err != nil {
 		gologger.Fatal().Msgf("Could not run enumeration: %s\n", err)
 	}
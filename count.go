@@ -0,0 +1,93 @@
+package uncover
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projectdiscovery/gologger"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// ExecuteCount returns the total number of results of every query and target on the agents
+// able to count them, without fetching the results
+func (s *Service) ExecuteCount(ctx context.Context, callback func(total sources.Total)) error {
+	if err := s.checkAgents(); err != nil {
+		return err
+	}
+	if callback == nil {
+		return errorutil.NewWithTag("uncover", "count callback cannot be nil")
+	}
+	var mu sync.Mutex
+	s.eachQuery(ctx, func(agent sources.Agent, query *sources.Query) {
+		counter, ok := agent.(sources.Counter)
+		if !ok {
+			gologger.Warning().Msgf("%s agent cannot count results without fetching them\n", agent.Name())
+			return
+		}
+		total, err := counter.Count(s.Session, query)
+		if err != nil {
+			gologger.Error().Label(agent.Name()).Msgf("Could not count results of %s: %s\n", query.Query, err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		callback(sources.Total{Source: agent.Name(), Query: query.Query, Total: total})
+	})
+	return nil
+}
+
+// ExecutePlan returns the translated query of every query and target on the agents with
+// the pages that would be fetched under the limit, no request is sent to the engines
+func (s *Service) ExecutePlan(ctx context.Context, callback func(plan sources.Plan)) error {
+	if err := s.checkAgents(); err != nil {
+		return err
+	}
+	if callback == nil {
+		return errorutil.NewWithTag("uncover", "plan callback cannot be nil")
+	}
+	var mu sync.Mutex
+	s.eachQuery(ctx, func(agent sources.Agent, query *sources.Query) {
+		mu.Lock()
+		defer mu.Unlock()
+		callback(sources.NewPlan(agent, query))
+	})
+	return nil
+}
+
+// eachQuery calls fn with the query of every agent for every input, inputs are run by
+// concurrent workers and the agents of an input concurrently
+func (s *Service) eachQuery(ctx context.Context, fn func(agent sources.Agent, query *sources.Query)) {
+	concurrency := s.Options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	inputs := s.inputStream(ctx)
+	workers := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for in := range inputs {
+				targetType := in.targetType
+				if in.target && targetType == "" {
+					targetType = sources.ClassifyTarget(in.value)
+				}
+				wg := &sync.WaitGroup{}
+				for _, agent := range s.Agents {
+					q, ok := s.agentQuery(agent, in, targetType)
+					if !ok {
+						continue
+					}
+					wg.Add(1)
+					go func(agent sources.Agent, query *sources.Query) {
+						defer wg.Done()
+						fn(agent, query)
+					}(agent, s.newQuery(agent, q))
+				}
+				wg.Wait()
+			}
+		}()
+	}
+	workers.Wait()
+}
@@ -0,0 +1,30 @@
+package uncover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestExecuteCount(t *testing.T) {
+	// the totals are counted without fetching any result
+	counter := &fakeAgent{count: func(query string) int { return len(query) * 1000 }}
+	s := newFakeService(counter, &Options{Queries: []string{"a", "abc"}, Limit: 250})
+	totals := map[string]int{}
+	require.Nil(t, s.ExecuteCount(context.Background(), func(total sources.Total) {
+		require.Equal(t, fakeEngine, total.Source)
+		totals[total.Query] = total.Total
+	}))
+	require.Equal(t, map[string]int{"a": 1000, "abc": 3000}, totals)
+
+	var plans []sources.Plan
+	counter.paging = sources.Paging{PageSize: 100, PageCost: 1, Unit: "points"}
+	require.Nil(t, s.ExecutePlan(context.Background(), func(plan sources.Plan) {
+		plans = append(plans, plan)
+	}))
+	require.Len(t, plans, 2)
+	require.Equal(t, 3, plans[0].Pages)
+	require.Equal(t, 3, plans[0].Credits)
+}
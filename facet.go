@@ -0,0 +1,130 @@
+package uncover
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/projectdiscovery/gologger"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// ExecuteFacets counts the results of every query and target by the values of fields, with
+// the aggregation api of the engines having one and by counting the fetched results otherwise
+func (s *Service) ExecuteFacets(ctx context.Context, fields []string, callback func(facet sources.Facet)) error {
+	if err := s.checkAgents(); err != nil {
+		return err
+	}
+	if callback == nil {
+		return errorutil.NewWithTag("uncover", "facet callback cannot be nil")
+	}
+	for _, field := range fields {
+		if !sources.IsFacetField(field) {
+			return errorutil.NewWithTag("uncover", "invalid facet %s, supported facets are %v", field, sources.FacetFields)
+		}
+	}
+	var mu sync.Mutex
+	send := func(facet sources.Facet) {
+		mu.Lock()
+		defer mu.Unlock()
+		callback(facet)
+	}
+	s.eachQuery(ctx, func(agent sources.Agent, query *sources.Query) {
+		s.executeFacets(ctx, agent, query, fields, send)
+	})
+	return nil
+}
+
+// executeFacets counts the results of query on agent, with its aggregation api when it has one
+func (s *Service) executeFacets(ctx context.Context, agent sources.Agent, query *sources.Query, fields []string, send func(sources.Facet)) {
+	var counted []string
+	faceter, _ := agent.(sources.Faceter)
+	for _, field := range fields {
+		if faceter == nil || !sliceutil.Contains(faceter.FacetFields(), field) {
+			counted = append(counted, field)
+			continue
+		}
+		facets, err := faceter.Facets(s.Session, query, field)
+		if err != nil {
+			gologger.Warning().Msgf("Could not aggregate %s results on %s, counting fetched results: %s\n", agent.Name(), field, err)
+			counted = append(counted, field)
+			continue
+		}
+		for _, facet := range facets {
+			facet.Source, facet.Query = agent.Name(), query.Query
+			send(facet)
+		}
+	}
+	if len(counted) > 0 {
+		s.countFacets(ctx, agent, query, counted, send)
+	}
+}
+
+// countFacets fetches the results of query, up to the limit, and counts them by the values of fields
+func (s *Service) countFacets(ctx context.Context, agent sources.Agent, query *sources.Query, fields []string, send func(sources.Facet)) {
+	ch, err := agent.Query(s.Session, query)
+	if err != nil {
+		gologger.Error().Msgf("%s\n", err)
+		return
+	}
+	counts := make(map[string]map[string]int, len(fields))
+	for _, field := range fields {
+		counts[field] = make(map[string]int)
+	}
+	results := 0
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case result, ok := <-ch:
+			if !ok {
+				break loop
+			}
+			if result.Error != nil {
+				gologger.Warning().Label(agent.Name()).Msgf("%s\n", result.Error)
+				continue
+			}
+			if !sources.SupportsTimeRange(agent.Name()) && !sources.InTimeRange(result, query.Since, query.Until) {
+				continue
+			}
+			results++
+			for _, field := range fields {
+				if value := sources.FacetValue(result, field); value != "" {
+					counts[field][value]++
+				}
+			}
+		}
+	}
+	gologger.Verbose().Msgf("Counted %d %s results of %s by %v\n", results, agent.Name(), query.Query, fields)
+	for _, field := range fields {
+		for _, facet := range SortFacets(counts[field], field) {
+			facet.Source, facet.Query, facet.Sampled = agent.Name(), query.Query, true
+			send(facet)
+		}
+	}
+}
+
+// SortFacets returns the facets of field from counts of values, the most common first
+func SortFacets(counts map[string]int, field string) []sources.Facet {
+	facets := make([]sources.Facet, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, sources.Facet{Field: field, Value: value, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		// numeric values such as ports are ordered as numbers
+		a, errA := strconv.Atoi(facets[i].Value)
+		b, errB := strconv.Atoi(facets[j].Value)
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	return facets
+}
@@ -0,0 +1,41 @@
+package uncover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestExecuteFacets(t *testing.T) {
+	// country is aggregated by the engine, the other fields are counted from raw results
+	faceter := &fakeAgent{
+		results: func(_ *sources.Query, _ int) []sources.Result {
+			return []sources.Result{
+				{IP: "10.0.0.1", Port: 443, Raw: []byte(`{"http":{"title":"Login"}}`)},
+				{IP: "10.0.0.2", Port: 443, Raw: []byte(`{"title":"Login"}`)},
+			}
+		},
+		facets: map[string][]sources.Facet{"country": {{Value: "US", Count: 1200}, {Value: "CN", Count: 30}}},
+	}
+	s := newFakeService(faceter, &Options{Queries: []string{"title=login"}})
+	counts := map[string]map[string]int{}
+	require.Nil(t, s.ExecuteFacets(context.Background(), []string{"country", "port", "title"}, func(facet sources.Facet) {
+		require.Equal(t, fakeEngine, facet.Source)
+		require.Equal(t, "title=login", facet.Query)
+		// the counts of the aggregation api are exact, the other ones are counted from the fetched results
+		require.Equal(t, facet.Field != "country", facet.Sampled, facet.Field)
+		if counts[facet.Field] == nil {
+			counts[facet.Field] = map[string]int{}
+		}
+		counts[facet.Field][facet.Value] += facet.Count
+	}))
+	require.Equal(t, map[string]map[string]int{
+		"country": {"US": 1200, "CN": 30},
+		"port":    {"443": 2},
+		"title":   {"Login": 2},
+	}, counts)
+
+	require.NotNil(t, s.ExecuteFacets(context.Background(), []string{"city"}, func(sources.Facet) {}))
+}
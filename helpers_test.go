@@ -0,0 +1,100 @@
+package uncover
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/wjlin0/uncover/sources"
+)
+
+// fakeEngine is the name of fakeAgent, no engine has it so no default applies to it
+const fakeEngine = "fake"
+
+// fakeAgent records the queries it is sent and the ones it counts, its unset fields make it
+// search every kind of target, count and aggregate nothing and fail to fetch results
+type fakeAgent struct {
+	// results returns the results of query, the nth one the agent is sent
+	results      func(query *sources.Query, n int) []sources.Result
+	count        func(query string) int
+	facets       map[string][]sources.Facet
+	paging       sources.Paging
+	capabilities []sources.TargetType
+
+	mu      sync.Mutex
+	queries []string
+	counted []string
+}
+
+func (agent *fakeAgent) Name() string {
+	return fakeEngine
+}
+
+func (agent *fakeAgent) Capabilities() []sources.TargetType {
+	if agent.capabilities == nil {
+		return sources.AllTargets
+	}
+	return agent.capabilities
+}
+
+func (agent *fakeAgent) Paging() sources.Paging {
+	return agent.paging
+}
+
+func (agent *fakeAgent) Query(_ *sources.Session, query *sources.Query) (chan sources.Result, error) {
+	if agent.results == nil {
+		return nil, errors.New("results must not be fetched")
+	}
+	agent.mu.Lock()
+	agent.queries = append(agent.queries, query.Query)
+	n := len(agent.queries)
+	agent.mu.Unlock()
+
+	results := make(chan sources.Result)
+	go func() {
+		defer close(results)
+		for _, result := range agent.results(query, n) {
+			result.Source = agent.Name()
+			results <- result
+		}
+	}()
+	return results, nil
+}
+
+func (agent *fakeAgent) Count(_ *sources.Session, query *sources.Query) (int, error) {
+	agent.mu.Lock()
+	agent.counted = append(agent.counted, query.Query)
+	agent.mu.Unlock()
+	if agent.count == nil {
+		return 0, nil
+	}
+	return agent.count(query.Query), nil
+}
+
+func (agent *fakeAgent) FacetFields() []string {
+	var fields []string
+	for field := range agent.facets {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func (agent *fakeAgent) Facets(_ *sources.Session, _ *sources.Query, field string) ([]sources.Facet, error) {
+	var facets []sources.Facet
+	for _, facet := range agent.facets[field] {
+		facet.Field = field
+		facets = append(facets, facet)
+	}
+	return facets, nil
+}
+
+// newFakeService returns a service running options with agent only, the provider has a
+// key as the fake engine isn't an anonymous one
+func newFakeService(agent sources.Agent, options *Options) *Service {
+	options.Agents = []string{agent.Name()}
+	return &Service{
+		Options:  options,
+		Agents:   []sources.Agent{agent},
+		Provider: &sources.Provider{Shodan: []string{"key"}},
+		Session:  &sources.Session{},
+	}
+}
@@ -231,7 +231,7 @@ func (h censysTestcases) Execute() error {
 	if secret == "" {
 		return errors.New("missing censys api secret")
 	}
-	censysToken := fmt.Sprintf(`censys: [%s:%S]`, id, secret)
+	censysToken := fmt.Sprintf(`censys: [%s:%s]`, id, secret)
 	_ = os.WriteFile(ConfigFile, []byte(censysToken), os.ModePerm)
 	defer os.RemoveAll(ConfigFile)
 	results, err := testutils.RunUncoverAndGetResults(debug, "-censys", "'services.software.vendor=Grafana'")
@@ -0,0 +1,179 @@
+package uncover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// DefaultPivotBudget is the maximum number of pivots queried per depth
+var DefaultPivotBudget = 100
+
+// pivoter feeds the pivots found in results back to the workers as new targets
+type pivoter struct {
+	depth  int
+	budget int
+	agents []sources.Agent
+	scope  *Scope
+
+	found chan input
+	done  chan struct{}
+
+	seen   map[string]struct{}
+	used   map[int]int
+	pivots []sources.Pivot
+	sync.Mutex
+}
+
+func newPivoter(options *Options, agents []sources.Agent) *pivoter {
+	p := &pivoter{
+		depth:  options.Depth,
+		budget: options.PivotBudget,
+		agents: agents,
+		scope:  NewScope(options.Scope),
+		found:  make(chan input),
+		done:   make(chan struct{}),
+		seen:   make(map[string]struct{}),
+		used:   make(map[int]int),
+	}
+	if p.budget <= 0 {
+		p.budget = DefaultPivotBudget
+	}
+	return p
+}
+
+// schedule hands initial inputs and pivots to the workers, it returns
+// once the initial inputs are consumed and no worker may find more pivots
+func (p *pivoter) schedule(ctx context.Context, initial <-chan input) <-chan input {
+	work := make(chan input)
+	go func() {
+		defer close(work)
+		var (
+			queue  []input
+			active int
+		)
+		for {
+			if initial == nil && len(queue) == 0 && active == 0 {
+				return
+			}
+			var (
+				next chan input
+				head input
+				in   <-chan input
+			)
+			if len(queue) > 0 {
+				next, head = work, queue[0]
+			} else {
+				// initial inputs are pulled only when idle to keep them streaming lazily
+				in = initial
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case value, ok := <-in:
+				if !ok {
+					initial = nil
+					continue
+				}
+				if value.target {
+					p.markSeen(value)
+				}
+				queue = append(queue, value)
+			case value := <-p.found:
+				queue = append(queue, value)
+			case next <- head:
+				queue = queue[1:]
+				active++
+			case <-p.done:
+				active--
+			}
+		}
+	}()
+	return work
+}
+
+// finish signals that a worker completed an input
+func (p *pivoter) finish(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case p.done <- struct{}{}:
+	}
+}
+
+// pivot extracts the pivots of result found by in and queues them
+func (p *pivoter) pivot(ctx context.Context, result sources.Result, in input) {
+	depth := in.depth + 1
+	if depth > p.depth || !p.scope.ContainsResult(result) {
+		return
+	}
+	from := result.Host
+	if from == "" {
+		from = result.IpPort()
+	}
+	for _, target := range sources.ExtractPivots(result) {
+		if !p.supported(target.Type) || !p.scope.ContainsTarget(target) || !p.take(target, depth) {
+			continue
+		}
+		pivot := &sources.Pivot{Type: target.Type, Value: target.Value, Depth: depth, Source: result.Source, From: from}
+		p.Lock()
+		p.pivots = append(p.pivots, *pivot)
+		p.Unlock()
+		gologger.Verbose().Msgf("pivot %s %s (depth %d) from %s found by %s\n", target.Type, target.Value, depth, from, result.Source)
+		select {
+		case <-ctx.Done():
+			return
+		case p.found <- input{value: target.Value, target: true, targetType: target.Type, depth: depth, pivot: pivot}:
+		}
+	}
+}
+
+// supported is true when any of the agents can search targets of targetType
+func (p *pivoter) supported(targetType sources.TargetType) bool {
+	for _, agent := range p.agents {
+		if sources.Supports(agent, targetType) {
+			return true
+		}
+	}
+	return false
+}
+
+// take reserves the budget of depth for an unseen target
+func (p *pivoter) take(target sources.Target, depth int) bool {
+	p.Lock()
+	defer p.Unlock()
+	key := pivotKey(target.Type, target.Value)
+	if _, ok := p.seen[key]; ok {
+		return false
+	}
+	if p.used[depth] >= p.budget {
+		return false
+	}
+	p.seen[key] = struct{}{}
+	p.used[depth]++
+	return true
+}
+
+func (p *pivoter) markSeen(in input) {
+	targetType := in.targetType
+	if targetType == "" {
+		targetType = sources.ClassifyTarget(in.value)
+	}
+	p.Lock()
+	p.seen[pivotKey(targetType, in.value)] = struct{}{}
+	p.Unlock()
+}
+
+// Pivots returns the pivots queried so far
+func (p *pivoter) Pivots() []sources.Pivot {
+	p.Lock()
+	defer p.Unlock()
+	return append([]sources.Pivot(nil), p.pivots...)
+}
+
+func pivotKey(targetType sources.TargetType, value string) string {
+	return fmt.Sprintf("%s|%s", targetType, strings.ToLower(value))
+}
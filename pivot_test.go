@@ -0,0 +1,63 @@
+package uncover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// siblingAgent returns a result on a sibling domain for every domain query
+func siblingAgent() *fakeAgent {
+	return &fakeAgent{
+		capabilities: []sources.TargetType{sources.TargetDomain},
+		results: func(query *sources.Query, n int) []sources.Result {
+			return []sources.Result{
+				{Host: "www." + query.Query, IP: "10.0.0.1", Port: 443},
+				{Host: "www.sibling" + string(rune('a'+n)) + ".com", Port: 443},
+			}
+		},
+	}
+}
+
+func TestRecursivePivot(t *testing.T) {
+	agent := siblingAgent()
+	s := newFakeService(agent, &Options{
+		Targets:   []string{"example.com"},
+		Recursive: true,
+		Depth:     2,
+	})
+	var results []sources.Result
+	require.Nil(t, s.ExecuteWithCallback(context.Background(), func(result sources.Result) {
+		results = append(results, result)
+	}))
+
+	// example.com at depth 0, then one new sibling at depth 1 and 2
+	require.Len(t, agent.queries, 3)
+	require.Len(t, results, 6)
+	pivots := s.Pivots()
+	require.Len(t, pivots, 2)
+	for _, pivot := range pivots {
+		require.Equal(t, sources.TargetDomain, pivot.Type)
+		require.LessOrEqual(t, pivot.Depth, 2)
+	}
+	for _, result := range results {
+		if result.Pivot != nil {
+			require.NotEmpty(t, result.Pivot.From)
+		}
+	}
+}
+
+func TestRecursivePivotScope(t *testing.T) {
+	agent := siblingAgent()
+	s := newFakeService(agent, &Options{
+		Targets:   []string{"example.com"},
+		Recursive: true,
+		Depth:     3,
+		Scope:     []string{"example.com"},
+	})
+	require.Nil(t, s.ExecuteWithCallback(context.Background(), func(result sources.Result) {}))
+	require.Len(t, agent.queries, 1)
+	require.Empty(t, s.Pivots())
+}
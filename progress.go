@@ -0,0 +1,198 @@
+package uncover
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// DefaultProgressInterval is the most often the progress of a run is saved
+var DefaultProgressInterval = time.Second
+
+// Progress is the state of the queries of a run, saved so that an interrupted run
+// skips the queries it finished and starts the other ones from their last page
+type Progress struct {
+	Queries map[string]*QueryProgress `json:"queries"`
+
+	path  string
+	saved time.Time
+	// pending are the results of each query sent but not received by the callback yet
+	pending map[string]int
+	// finished are the queries whose agent returned every result
+	finished map[string]bool
+	sync.Mutex
+}
+
+// QueryProgress is the state of a query on an agent
+type QueryProgress struct {
+	// Results are the results of the query received by the callback
+	Results int  `json:"results"`
+	Done    bool `json:"done,omitempty"`
+}
+
+// LoadProgress reads the progress saved at path, an empty progress is returned when
+// there is none
+func LoadProgress(path string) (*Progress, error) {
+	p := &Progress{Queries: make(map[string]*QueryProgress), path: path, pending: make(map[string]int), finished: make(map[string]bool)}
+	if !fileutil.FileExists(path) {
+		return p, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not read progress %s", path)
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not decode progress %s", path)
+	}
+	if p.Queries == nil {
+		p.Queries = make(map[string]*QueryProgress)
+	}
+	return p, nil
+}
+
+func progressKey(agent, query string) string {
+	return agent + "|" + query
+}
+
+// Done reports whether every result of query on agent was received in a previous run
+func (p *Progress) Done(agent, query string) bool {
+	if p == nil {
+		return false
+	}
+	p.Lock()
+	defer p.Unlock()
+	state, ok := p.Queries[progressKey(agent, query)]
+	return ok && state.Done
+}
+
+// Start returns the number of pages of size of query on agent already received, the
+// results of the last incomplete page are received again
+func (p *Progress) Start(agent, query string, size int) int {
+	if p == nil || size <= 0 {
+		return 0
+	}
+	p.Lock()
+	defer p.Unlock()
+	state, ok := p.Queries[progressKey(agent, query)]
+	if !ok {
+		p.Queries[progressKey(agent, query)] = &QueryProgress{}
+		return 0
+	}
+	page := state.Results / size
+	state.Results = page * size
+	return page
+}
+
+// Sent records a result of query on agent sent to the callback
+func (p *Progress) Sent(agent, query string) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.pending[progressKey(agent, query)]++
+}
+
+// Add records a result of query on agent received by the callback
+func (p *Progress) Add(agent, query string) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	key := progressKey(agent, query)
+	state, ok := p.Queries[key]
+	if !ok {
+		state = &QueryProgress{}
+		p.Queries[key] = state
+	}
+	state.Results++
+	p.pending[key]--
+	p.complete(key)
+	if time.Since(p.saved) >= DefaultProgressInterval {
+		_ = p.save()
+	}
+}
+
+// Finish records that agent returned every result of query
+func (p *Progress) Finish(agent, query string) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	key := progressKey(agent, query)
+	p.finished[key] = true
+	p.complete(key)
+}
+
+// complete marks the query of key done once it finished and the callback received all of its results
+func (p *Progress) complete(key string) {
+	if !p.finished[key] || p.pending[key] > 0 {
+		return
+	}
+	state, ok := p.Queries[key]
+	if !ok {
+		state = &QueryProgress{}
+		p.Queries[key] = state
+	}
+	state.Done = true
+	delete(p.finished, key)
+	delete(p.pending, key)
+}
+
+// Save writes the progress to its file
+func (p *Progress) Save() error {
+	if p == nil {
+		return nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	return p.save()
+}
+
+func (p *Progress) save() error {
+	p.saved = time.Now()
+	if err := fileutil.CreateFolders(filepath.Dir(p.path)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	// the progress is replaced at once so that an interruption never leaves half of it
+	if err := os.WriteFile(p.path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(p.path+".tmp", p.path)
+}
+
+// Clear forgets the progress of every query and removes its file
+func (p *Progress) Clear() error {
+	if p == nil {
+		return nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Queries = make(map[string]*QueryProgress)
+	p.pending = make(map[string]int)
+	p.finished = make(map[string]bool)
+	if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// resume starts query after its pages on agent already received
+func (p *Progress) resume(agent sources.Agent, query *sources.Query) {
+	if _, ok := sources.PagingOf(agent); !ok {
+		return
+	}
+	query.Page = p.Start(agent.Name(), query.Query, sources.NewPlan(agent, query).PageSize)
+}
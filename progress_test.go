@@ -0,0 +1,39 @@
+package uncover
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	progress, err := LoadProgress(path)
+	require.Nil(t, err)
+	require.Equal(t, 0, progress.Start("fofa", "title=x", 10))
+	for i := 0; i < 25; i++ {
+		progress.Sent("fofa", "title=x")
+		progress.Add("fofa", "title=x")
+	}
+	// a query is done once the callback received every result its agent sent
+	progress.Sent("shodan", "port:22")
+	progress.Finish("shodan", "port:22")
+	require.False(t, progress.Done("shodan", "port:22"))
+	progress.Add("shodan", "port:22")
+	require.True(t, progress.Done("shodan", "port:22"))
+	require.Nil(t, progress.Save())
+
+	resumed, err := LoadProgress(path)
+	require.Nil(t, err)
+	require.True(t, resumed.Done("shodan", "port:22"))
+	require.False(t, resumed.Done("fofa", "title=x"))
+	// the incomplete third page is fetched again
+	require.Equal(t, 2, resumed.Start("fofa", "title=x", 10))
+	require.Equal(t, 20, resumed.Queries["fofa|title=x"].Results)
+
+	require.Nil(t, resumed.Clear())
+	require.NoFileExists(t, path)
+	var none *Progress
+	require.False(t, none.Done("fofa", "title=x"))
+}
@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// writeBlockedSummary warns of the agents blocked during the run and the searches
+// they attempted, so that missing results are not mistaken for no results
+func writeBlockedSummary(blocked []*sources.BlockedError) {
+	if len(blocked) == 0 {
+		return
+	}
+	var counted, attempted int
+	for _, err := range blocked {
+		searches := "searches unknown"
+		if err.Searches > 0 {
+			searches = fmt.Sprintf("on search %d, the searches after it were skipped", err.Searches)
+			counted++
+			attempted += err.Searches
+		}
+		gologger.Warning().Label(err.Source).Msgf("Blocked by a %s page for %s after %d results %s\n", err.Reason, err.Query, err.Results, searches)
+	}
+	if attempted > 0 {
+		gologger.Warning().Msgf("%d blocked queries, %d of %d attempted searches blocked\n", len(blocked), counted, attempted)
+		return
+	}
+	gologger.Warning().Msgf("%d blocked queries\n", len(blocked))
+}
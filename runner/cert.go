@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+	"github.com/wjlin0/uncover/utils"
+)
+
+// certTargets returns the pivot targets of every -cert value: the certificate
+// fingerprint, its subject organizations and the domains of its SANs. an error is
+// returned for fingerprints none of the engines can search
+func (r *Runner) certTargets() ([]sources.Target, error) {
+	var targets []sources.Target
+	for _, value := range r.options.Cert {
+		value = strings.TrimSpace(value)
+		if sources.FingerprintReg.MatchString(value) {
+			if !r.searchable(value, sources.TargetCert) {
+				return nil, errorutil.New("none of the engines can search the certificate fingerprint %s, give the certificate or another fingerprint instead", value)
+			}
+			targets = append(targets, sources.Target{Value: strings.ToLower(value), Type: sources.TargetCert})
+			continue
+		}
+		info, err := r.readCert(value)
+		if err != nil {
+			gologger.Error().Msgf("couldn't read certificate %s: %s\n", value, err)
+			continue
+		}
+		gologger.Info().Msgf("certificate %s sha1: %s sha256: %s serial: %s cn: %s org: %s sans: %s\n",
+			value, info.SHA1, info.SHA256, info.Serial, info.CommonName, strings.Join(info.Organization, ","), strings.Join(info.SANs, ","))
+
+		targets = append(targets, sources.Target{Value: info.SHA1, Type: sources.TargetCert})
+		for _, org := range info.Organization {
+			targets = append(targets, sources.Target{Value: org, Type: sources.TargetOrg})
+		}
+		for _, domain := range info.Domains() {
+			targets = append(targets, sources.Target{Value: domain, Type: sources.TargetDomain})
+		}
+	}
+	return targets, nil
+}
+
+// searchable is true when any of the agents can search target of kind targetType
+func (r *Runner) searchable(target string, targetType sources.TargetType) bool {
+	for _, agent := range r.service.Agents {
+		if _, ok := sources.TargetQuery(target, targetType, agent); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readCert parses a PEM file or fetches the certificate served at host:port
+func (r *Runner) readCert(value string) (*utils.CertInfo, error) {
+	if fileutil.FileExists(value) {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		return utils.ParseCertificatePEM(data)
+	}
+	if !strings.Contains(value, ":") {
+		return nil, errorutil.New("%s is neither a pem file, host:port nor a sha1/sha256 fingerprint", value)
+	}
+	return utils.FetchCertificate(value, time.Duration(r.options.Timeout)*time.Second)
+}
@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// runCount writes the total number of results of each query per engine instead of the results
+func (r *Runner) runCount(ctx context.Context) error {
+	r.service.Options.QueryStream = r.options.queryStream(ctx)
+	r.service.Options.TargetStream = r.options.targetStream(ctx)
+	return r.service.ExecuteCount(ctx, func(total sources.Total) {
+		line := fmt.Sprintf("%s\t%s\t%d", total.Source, total.Query, total.Total)
+		r.writeLine(total, line)
+	})
+}
+
+// runDryRun writes the query sent to each engine and what fetching its results would cost
+func (r *Runner) runDryRun(ctx context.Context) error {
+	r.service.Options.QueryStream = r.options.queryStream(ctx)
+	r.service.Options.TargetStream = r.options.targetStream(ctx)
+	return r.service.ExecutePlan(ctx, func(plan sources.Plan) {
+		line := fmt.Sprintf("%s\t%s\tpages=unknown", plan.Source, plan.Query)
+		if plan.Pages > 0 {
+			line = fmt.Sprintf("%s\t%s\tpages=%d (%d per page)", plan.Source, plan.Query, plan.Pages, plan.PageSize)
+			if plan.Unit != "" {
+				line += fmt.Sprintf("\tcredits=%d %s", plan.Credits, plan.Unit)
+			}
+		}
+		r.writeLine(plan, line)
+	})
+}
+
+// writeLine writes value as json or line as is
+func (r *Runner) writeLine(value interface{}, line string) {
+	if r.options.JSON {
+		data, _ := json.Marshal(value)
+		line = string(data)
+	}
+	r.outputWriter.Write([]byte(line))
+	gologger.DefaultLogger.Print().Msg(line)
+}
@@ -0,0 +1,193 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/projectdiscovery/goflags"
+	"github.com/projectdiscovery/gologger"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// DBOptions are the options of the db command searching the result store
+type DBOptions struct {
+	Location   string
+	Host       string
+	IP         string
+	Port       int
+	Source     string
+	Query      string
+	Run        string
+	Since      string
+	Until      string
+	New        bool
+	History    bool
+	Runs       bool
+	Limit      int
+	JSON       bool
+	OutputFile string
+	Silent     bool
+	NoColor    bool
+}
+
+// ParseDBOptions parses the flags of the db command, os.Args must hold the
+// command flags without the db argument
+func ParseDBOptions() *DBOptions {
+	options := &DBOptions{}
+	flagSet := goflags.NewFlagSet()
+	flagSet.SetDescription(`search the history of the results recorded with -db.`)
+
+	flagSet.CreateGroup("filter", "Filter",
+		flagSet.StringVarP(&options.Host, "host", "hs", "", "host to search, * is a wildcard (example: -host '*.example.com')"),
+		flagSet.StringVar(&options.IP, "ip", "", "ip to search, * is a wildcard (example: -ip '10.0.0.*')"),
+		flagSet.IntVarP(&options.Port, "port", "p", 0, "port to search"),
+		flagSet.StringVarP(&options.Source, "source", "s", "", "engine which returned the results (example: -source fofa)"),
+		flagSet.StringVarP(&options.Query, "query", "q", "", "query which returned the results, * is a wildcard"),
+		flagSet.StringVar(&options.Run, "run", "", "id of the run which recorded the results"),
+		flagSet.StringVar(&options.Since, "since", "", "results seen since date, datetime or duration ago (example: -since 2024-01-01, -since 24h)"),
+		flagSet.StringVar(&options.Until, "until", "", "results seen until date, datetime or duration ago (example: -until 2024-02-01)"),
+		flagSet.BoolVar(&options.New, "new", false, "only assets first seen since -since"),
+	)
+
+	flagSet.CreateGroup("output", "Output",
+		flagSet.StringVarP(&options.Location, "db", "d", DefaultDBLocation, "sqlite database to search"),
+		flagSet.BoolVar(&options.History, "history", false, "list every recorded result instead of unique assets"),
+		flagSet.BoolVar(&options.Runs, "runs", false, "list the recorded runs"),
+		flagSet.IntVarP(&options.Limit, "limit", "l", 0, "maximum number of records to show"),
+		flagSet.BoolVarP(&options.JSON, "json", "j", false, "write output in JSONL(ines) format"),
+		flagSet.StringVarP(&options.OutputFile, "output", "o", "", "output file to write the records to"),
+		flagSet.BoolVar(&options.Silent, "silent", false, "show only records in output"),
+		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable colors in output"),
+	)
+
+	if err := flagSet.Parse(); err != nil {
+		gologger.Fatal().Msgf("Program exiting: %s\n", err)
+	}
+	(&Options{Silent: options.Silent, NoColor: options.NoColor}).configureOutput()
+	return options
+}
+
+// RunDB searches the result store and writes the matching records
+func RunDB(options *DBOptions) error {
+	filter := StoreFilter{
+		Host:   options.Host,
+		IP:     options.IP,
+		Port:   options.Port,
+		Source: options.Source,
+		Query:  options.Query,
+		Run:    options.Run,
+		New:    options.New,
+		Limit:  options.Limit,
+	}
+	var err error
+	if filter.Since, err = ParseTime(options.Since); err != nil {
+		return err
+	}
+	if filter.Until, err = ParseTime(options.Until); err != nil {
+		return err
+	}
+	if options.New && filter.Since.IsZero() {
+		return errorutil.New("-new requires -since")
+	}
+
+	store, err := OpenStore(options.Location)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var writer io.Writer = os.Stdout
+	if options.OutputFile != "" {
+		file, err := os.Create(options.OutputFile)
+		if err != nil {
+			return errorutil.New("could not create output file %s: %s", options.OutputFile, err)
+		}
+		defer file.Close()
+		writer = file
+	}
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	defer table.Flush()
+
+	var records []interface{}
+	switch {
+	case options.Runs:
+		runs, err := store.Runs(options.Limit)
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			records = append(records, run)
+			if !options.JSON {
+				fmt.Fprintf(table, "%s\t%s\t%s\t%d\n", run.ID, formatTime(run.StartedAt), formatTime(run.FinishedAt), run.Results)
+			}
+		}
+	case options.History:
+		results, err := store.History(filter)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			records = append(records, result)
+			if !options.JSON {
+				fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", formatTime(result.SeenAt), result.IpPort(), result.Host, result.Source, result.Run, result.Query)
+			}
+		}
+	default:
+		assets, err := store.Assets(filter)
+		if err != nil {
+			return err
+		}
+		for _, asset := range assets {
+			records = append(records, asset)
+			if !options.JSON {
+				fmt.Fprintf(table, "%s:%d\t%s\t%s\t%s\t%s\n", asset.IP, asset.Port, asset.Host, formatTime(asset.FirstSeen), formatTime(asset.LastSeen), strings.Join(asset.Sources, ","))
+			}
+		}
+	}
+	if options.JSON {
+		encoder := json.NewEncoder(writer)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+	}
+	gologger.Info().Msgf("Found %d records in %s\n", len(records), options.Location)
+	return nil
+}
+
+// ParseTime parses a date (2006-01-02), an RFC3339 datetime or a duration
+// ago (24h, 7d), the zero time is returned for an empty value
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		value = days + "h"
+		if duration, err := time.ParseDuration(value); err == nil {
+			return time.Now().Add(-duration * 24), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	return time.Time{}, errorutil.New("invalid time %s, use a date (2006-01-02), a datetime (2006-01-02T15:04:05Z07:00) or a duration ago (24h, 7d)", value)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,361 @@
+package runner
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bits-and-blooms/bloom/v3"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/hmap/store/hybrid"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+)
+
+const (
+	DedupeBackendAuto   = "auto"
+	DedupeBackendMemory = "memory"
+	DedupeBackendDisk   = "disk"
+	DedupeBackendBloom  = "bloom"
+)
+
+var (
+	// DefaultDedupeFields are the result fields used as de-duplication key
+	DefaultDedupeFields = []string{"ip", "port", "host"}
+	// DefaultMaxInMemoryDedupe is the number of keys kept in memory
+	// before the auto backend spills to disk
+	DefaultMaxInMemoryDedupe = 1_000_000
+	// DefaultBloomFalsePositive is the false positive rate of the bloom backend
+	DefaultBloomFalsePositive = 0.0001
+)
+
+// DedupeOptions configures the de-duplication of written results
+type DedupeOptions struct {
+	// Fields of sources.Result making up the key (ip,port,host,url,source)
+	Fields []string
+	// Backend is one of auto, memory, disk or bloom
+	Backend string
+	// MaxInMemory is the number of keys after which auto spills to disk
+	// and the expected number of keys for the bloom backend
+	MaxInMemory int
+	// Path is where Save persists the store and where it is loaded from, the auto
+	// backend spills there. when empty a temporary store is used and removed on close
+	Path string
+}
+
+// dedupeStore is a set of keys
+type dedupeStore interface {
+	// Add adds key to the store and reports whether it was not present
+	Add(key []byte) bool
+	Close() error
+}
+
+// Dedupe drops results which were already written
+type Dedupe struct {
+	options *DedupeOptions
+	store   dedupeStore
+	count   int
+	dropped atomic.Int64
+	sync.Mutex
+}
+
+// NewDedupe creates a new de-duplicator from options
+func NewDedupe(options *DedupeOptions) (*Dedupe, error) {
+	if options == nil {
+		options = &DedupeOptions{}
+	}
+	if len(options.Fields) == 0 {
+		options.Fields = DefaultDedupeFields
+	}
+	for _, field := range options.Fields {
+		if _, ok := resultField(sources.Result{}, field); !ok {
+			return nil, errorutil.New("invalid dedupe field %s, supported fields are ip,port,host,url,source", field)
+		}
+	}
+	if options.Backend == "" {
+		options.Backend = DedupeBackendAuto
+	}
+	if options.MaxInMemory <= 0 {
+		options.MaxInMemory = DefaultMaxInMemoryDedupe
+	}
+
+	if options.Backend == DedupeBackendBloom {
+		gologger.Warning().Msgf("The bloom dedupe backend may drop %g%% of the unique results as duplicates\n", DefaultBloomFalsePositive*100)
+	}
+
+	d := &Dedupe{options: options}
+	if err := d.open(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// open creates the store of the backend of the options
+func (d *Dedupe) open() error {
+	var err error
+	switch d.options.Backend {
+	case DedupeBackendMemory, DedupeBackendAuto:
+		d.store = newMemoryStore()
+		if d.options.Path != "" && fileutil.FolderExists(d.options.Path) {
+			err = d.load()
+		}
+	case DedupeBackendDisk:
+		d.store, err = newDiskStore(d.options.Path)
+	case DedupeBackendBloom:
+		d.store, err = newBloomStore(d.options.Path, d.options.MaxInMemory)
+	default:
+		return errorutil.New("invalid dedupe backend %s, supported backends are auto,memory,disk,bloom", d.options.Backend)
+	}
+	return err
+}
+
+// load adds the keys persisted at Path to the in-memory store, spilling to disk when
+// they are too many for the auto backend
+func (d *Dedupe) load() error {
+	disk, err := newDiskStore(d.options.Path)
+	if err != nil {
+		return err
+	}
+	memory := d.store.(*memoryStore)
+	disk.db.Scan(func(key, _ []byte) error {
+		memory.keys[string(key)] = struct{}{}
+		return nil
+	})
+	d.count = len(memory.keys)
+	if err := disk.Close(); err != nil {
+		return err
+	}
+	if d.options.Backend == DedupeBackendAuto && d.count >= d.options.MaxInMemory {
+		d.spill()
+	}
+	return nil
+}
+
+// Save persists the keys seen so far at Path, so that the next Dedupe of Path drops them
+func (d *Dedupe) Save() error {
+	d.Lock()
+	defer d.Unlock()
+	if d.options.Path == "" {
+		return nil
+	}
+	switch store := d.store.(type) {
+	case *memoryStore:
+		disk, err := newDiskStore(d.options.Path)
+		if err != nil {
+			return err
+		}
+		for key := range store.keys {
+			disk.Add([]byte(key))
+		}
+		return disk.Close()
+	case *bloomStore:
+		return store.save()
+	}
+	// disk stores at Path write every key as it is added
+	return nil
+}
+
+// Reset forgets every key seen so far, including the persisted ones
+func (d *Dedupe) Reset() error {
+	d.Lock()
+	defer d.Unlock()
+	if err := d.store.Close(); err != nil {
+		return err
+	}
+	if d.options.Path != "" {
+		if err := os.RemoveAll(d.options.Path); err != nil {
+			return err
+		}
+	}
+	d.count = 0
+	return d.open()
+}
+
+// Key returns the de-duplication key of result
+func (d *Dedupe) Key(result sources.Result) string {
+	parts := make([]string, 0, len(d.options.Fields))
+	for _, field := range d.options.Fields {
+		value, _ := resultField(result, field)
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, "|")
+}
+
+// IsDuplicate reports whether data was already seen and records it otherwise
+func (d *Dedupe) IsDuplicate(data string) bool {
+	itemHash := sha1.Sum([]byte(data))
+
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.store.Add(itemHash[:]) {
+		d.dropped.Add(1)
+		return true
+	}
+	d.count++
+	if d.options.Backend == DedupeBackendAuto && d.count == d.options.MaxInMemory {
+		d.spill()
+	}
+	return false
+}
+
+// Dropped returns the number of duplicates seen so far
+func (d *Dedupe) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// Close closes the underlying store
+func (d *Dedupe) Close() error {
+	d.Lock()
+	defer d.Unlock()
+	return d.store.Close()
+}
+
+// spill moves the in-memory keys to a disk store
+func (d *Dedupe) spill() {
+	memory, ok := d.store.(*memoryStore)
+	if !ok {
+		return
+	}
+	disk, err := newDiskStore(d.options.Path)
+	if err != nil {
+		gologger.Warning().Msgf("couldn't spill dedupe keys to disk, keeping them in memory: %s\n", err)
+		return
+	}
+	gologger.Verbose().Msgf("dedupe store reached %d keys, spilling to disk\n", d.count)
+	for key := range memory.keys {
+		disk.Add([]byte(key))
+	}
+	d.store = disk
+}
+
+// resultField returns the string value of a named result field
+func resultField(result sources.Result, field string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(field)) {
+	case "ip":
+		return result.IP, true
+	case "port":
+		return fmt.Sprint(result.Port), true
+	case "host":
+		return result.Host, true
+	case "url":
+		return result.Url, true
+	case "source":
+		return result.Source, true
+	default:
+		return "", false
+	}
+}
+
+// memoryStore is an exact in-memory set
+type memoryStore struct {
+	keys map[string]struct{}
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{keys: make(map[string]struct{})}
+}
+
+func (m *memoryStore) Add(key []byte) bool {
+	if _, ok := m.keys[string(key)]; ok {
+		return false
+	}
+	m.keys[string(key)] = struct{}{}
+	return true
+}
+
+func (m *memoryStore) Close() error {
+	return nil
+}
+
+// diskStore is an exact leveldb backed set
+type diskStore struct {
+	db *hybrid.HybridMap
+}
+
+func newDiskStore(path string) (*diskStore, error) {
+	options := hybrid.DefaultDiskOptions
+	if path != "" {
+		if err := fileutil.CreateFolders(path); err != nil {
+			return nil, err
+		}
+		options.Path = path
+		options.Cleanup = false
+	}
+	db, err := hybrid.New(options)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("couldn't create dedupe store")
+	}
+	return &diskStore{db: db}, nil
+}
+
+func (s *diskStore) Add(key []byte) bool {
+	if _, ok := s.db.Get(string(key)); ok {
+		return false
+	}
+	if err := s.db.Set(string(key), nil); err != nil {
+		gologger.Error().Msgf("dedupe: couldn't write key: %s\n", err)
+	}
+	return true
+}
+
+func (s *diskStore) Close() error {
+	return s.db.Close()
+}
+
+// bloomStore is a probabilistic set, it never lets a duplicate through but may
+// report a new key as duplicate at DefaultBloomFalsePositive
+type bloomStore struct {
+	filter *bloom.BloomFilter
+	path   string
+}
+
+func newBloomStore(path string, n int) (*bloomStore, error) {
+	s := &bloomStore{filter: bloom.NewWithEstimates(uint(n), DefaultBloomFalsePositive)}
+	if path == "" {
+		return s, nil
+	}
+	if err := fileutil.CreateFolders(path); err != nil {
+		return nil, err
+	}
+	s.path = filepath.Join(path, "dedupe.bloom")
+	if !fileutil.FileExists(s.path) {
+		return s, nil
+	}
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := s.filter.ReadFrom(file); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("couldn't load bloom filter from %s", s.path)
+	}
+	return s, nil
+}
+
+func (s *bloomStore) Add(key []byte) bool {
+	return !s.filter.TestAndAdd(key)
+}
+
+func (s *bloomStore) Close() error {
+	return nil
+}
+
+// save writes the filter to its path
+func (s *bloomStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	file, err := os.Create(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = s.filter.WriteTo(file)
+	return err
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestDedupeKey(t *testing.T) {
+	dedupe, err := NewDedupe(nil)
+	require.Nil(t, err)
+	defer dedupe.Close()
+
+	a := sources.Result{IP: "1.1.1.1", Port: 443, Host: "a.example.com"}
+	b := sources.Result{IP: "1.1.1.1", Port: 443, Host: "b.example.com"}
+	require.False(t, dedupe.IsDuplicate(dedupe.Key(a)))
+	require.False(t, dedupe.IsDuplicate(dedupe.Key(b)), "distinct hosts on the same ip:port must be kept")
+	require.True(t, dedupe.IsDuplicate(dedupe.Key(a)))
+	require.Equal(t, int64(1), dedupe.Dropped())
+
+	_, err = NewDedupe(&DedupeOptions{Fields: []string{"banner"}})
+	require.NotNil(t, err)
+}
+
+func TestDedupeBackends(t *testing.T) {
+	for _, backend := range []string{DedupeBackendMemory, DedupeBackendDisk, DedupeBackendBloom} {
+		dedupe, err := NewDedupe(&DedupeOptions{Backend: backend})
+		require.Nil(t, err, backend)
+		require.False(t, dedupe.IsDuplicate("1.1.1.1:80"), backend)
+		require.True(t, dedupe.IsDuplicate("1.1.1.1:80"), backend)
+		require.Nil(t, dedupe.Reset(), backend)
+		require.False(t, dedupe.IsDuplicate("1.1.1.1:80"), backend)
+		require.Nil(t, dedupe.Close(), backend)
+	}
+}
+
+func TestDedupeSpill(t *testing.T) {
+	dedupe, err := NewDedupe(&DedupeOptions{MaxInMemory: 2})
+	require.Nil(t, err)
+	defer dedupe.Close()
+
+	require.False(t, dedupe.IsDuplicate("a"))
+	require.False(t, dedupe.IsDuplicate("b"))
+	require.IsType(t, &diskStore{}, dedupe.store)
+	require.True(t, dedupe.IsDuplicate("a"))
+	require.False(t, dedupe.IsDuplicate("c"))
+}
+
+func TestDedupePersisted(t *testing.T) {
+	for _, backend := range []string{DedupeBackendAuto, DedupeBackendBloom} {
+		path := t.TempDir()
+		dedupe, err := NewDedupe(&DedupeOptions{Backend: backend, Path: path})
+		require.Nil(t, err, backend)
+		require.False(t, dedupe.IsDuplicate("a"), backend)
+		require.Nil(t, dedupe.Save(), backend)
+		require.Nil(t, dedupe.Close(), backend)
+
+		dedupe, err = NewDedupe(&DedupeOptions{Backend: backend, Path: path})
+		require.Nil(t, err, backend)
+		require.True(t, dedupe.IsDuplicate("a"), backend)
+		if backend == DedupeBackendAuto {
+			require.IsType(t, &memoryStore{}, dedupe.store, "persisted keys are loaded in memory")
+		}
+		require.Nil(t, dedupe.Close(), backend)
+	}
+}
@@ -0,0 +1,237 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+)
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// DefaultDiffFields are the result fields identifying a record between snapshots
+var DefaultDiffFields = []string{"ip", "port"}
+
+// DiffOptions configures the comparison of a run with a previous one
+type DiffOptions struct {
+	// Previous is a jsonl file or the name of a stored snapshot to compare with
+	Previous string
+	// Snapshot is the name under which the results of the run are stored,
+	// it is also compared with when Previous is empty
+	Snapshot string
+	// Fields of sources.Result identifying a record (ip,port,host,url,source),
+	// records with the same key but different ip, port or host are changed
+	Fields []string
+}
+
+// Differ reports the results added, changed or removed since a previous run
+type Differ struct {
+	key      []string
+	compare  []string
+	previous map[string][]sources.Result
+	seen     map[string]struct{}
+	loaded   bool
+
+	snapshot     *os.File
+	snapshotPath string
+	sync.Mutex
+}
+
+// NewDiffer loads the previous run described by options
+func NewDiffer(options *DiffOptions) (*Differ, error) {
+	d := &Differ{
+		key:      options.Fields,
+		previous: make(map[string][]sources.Result),
+		seen:     make(map[string]struct{}),
+	}
+	if len(d.key) == 0 {
+		d.key = DefaultDiffFields
+	}
+	for _, field := range d.key {
+		if _, ok := resultField(sources.Result{}, field); !ok {
+			return nil, errorutil.New("invalid diff key %s, supported fields are ip,port,host,url,source", field)
+		}
+	}
+	// the identity fields not part of the key tell a changed record apart
+	for _, field := range DefaultDedupeFields {
+		if !containsField(d.key, field) {
+			d.compare = append(d.compare, field)
+		}
+	}
+
+	previous := options.Previous
+	switch {
+	case previous != "" && !fileutil.FileExists(previous):
+		path, err := snapshotPath(previous)
+		if err != nil || !fileutil.FileExists(path) {
+			return nil, errorutil.New("no previous results file or snapshot named %s found", previous)
+		}
+		previous = path
+	case previous == "" && options.Snapshot != "":
+		path, err := snapshotPath(options.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		if fileutil.FileExists(path) {
+			previous = path
+		}
+	}
+	if previous != "" {
+		if err := d.load(previous); err != nil {
+			return nil, err
+		}
+		d.loaded = true
+	}
+
+	if options.Snapshot != "" {
+		path, err := snapshotPath(options.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		if err := fileutil.CreateFolders(filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+		// the snapshot is replaced only once the run completed
+		if d.snapshot, err = os.Create(path + ".tmp"); err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("could not create snapshot %s", path)
+		}
+		d.snapshotPath = path
+	}
+	return d, nil
+}
+
+// load reads the jsonl results of a previous run
+func (d *Differ) load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not open previous results %s", file)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var result sources.Result
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not decode previous result %q", line)
+		}
+		key := d.fields(result, d.key)
+		d.previous[key] = append(d.previous[key], result)
+	}
+	return scanner.Err()
+}
+
+// HasPrevious is false on a first run without previous results to compare with
+func (d *Differ) HasPrevious() bool {
+	return d.loaded
+}
+
+// Compare records result in the snapshot and returns its change since the
+// previous run, false is returned when it did not change
+func (d *Differ) Compare(result sources.Result) (string, bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.snapshot != nil {
+		_, _ = d.snapshot.WriteString(result.JSON() + "\n")
+	}
+	key := d.fields(result, d.key)
+	d.seen[key] = struct{}{}
+	previous, ok := d.previous[key]
+	if !ok {
+		return ChangeAdded, true
+	}
+	current := d.fields(result, d.compare)
+	for _, old := range previous {
+		if d.fields(old, d.compare) == current {
+			return "", false
+		}
+	}
+	return ChangeChanged, true
+}
+
+// Removed returns the previous records whose key was not seen in this run
+func (d *Differ) Removed() []sources.Result {
+	d.Lock()
+	defer d.Unlock()
+
+	var removed []sources.Result
+	for key, results := range d.previous {
+		if _, ok := d.seen[key]; ok {
+			continue
+		}
+		for _, result := range results {
+			result.Change = ChangeRemoved
+			removed = append(removed, result)
+		}
+	}
+	return removed
+}
+
+// Save replaces the stored snapshot with the results of this run
+func (d *Differ) Save() error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.snapshot == nil {
+		return nil
+	}
+	tmp := d.snapshot.Name()
+	if err := d.snapshot.Close(); err != nil {
+		return err
+	}
+	d.snapshot = nil
+	return os.Rename(tmp, d.snapshotPath)
+}
+
+// Close discards the snapshot of a run which was not saved
+func (d *Differ) Close() {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.snapshot != nil {
+		_ = d.snapshot.Close()
+		_ = os.Remove(d.snapshot.Name())
+		d.snapshot = nil
+	}
+}
+
+func (d *Differ) fields(result sources.Result, fields []string) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		value, _ := resultField(result, field)
+		parts = append(parts, strings.ToLower(value))
+	}
+	return strings.Join(parts, "|")
+}
+
+// snapshotPath returns the file of the snapshot named name
+func snapshotPath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", errorutil.New("invalid snapshot name %s", name)
+	}
+	return filepath.Join(DefaultSnapshotLocation, name+".jsonl"), nil
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if strings.EqualFold(strings.TrimSpace(f), field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestDiffer(t *testing.T) {
+	previous := filepath.Join(t.TempDir(), "previous.jsonl")
+	old := []sources.Result{
+		{Source: "fofa", IP: "1.1.1.1", Port: 443, Host: "www.example.com"},
+		{Source: "fofa", IP: "1.1.1.1", Port: 80, Host: "www.example.com"},
+		{Source: "fofa", IP: "2.2.2.2", Port: 22},
+	}
+	var data []byte
+	for _, result := range old {
+		data = append(data, []byte(result.JSON()+"\n")...)
+	}
+	require.Nil(t, os.WriteFile(previous, data, 0600))
+
+	differ, err := NewDiffer(&DiffOptions{Previous: previous})
+	require.Nil(t, err)
+	defer differ.Close()
+
+	_, changed := differ.Compare(sources.Result{Source: "shodan", IP: "1.1.1.1", Port: 443, Host: "WWW.example.com"})
+	require.False(t, changed, "same record found by another engine is unchanged")
+
+	change, changed := differ.Compare(sources.Result{IP: "1.1.1.1", Port: 80, Host: "api.example.com"})
+	require.True(t, changed)
+	require.Equal(t, ChangeChanged, change)
+
+	change, _ = differ.Compare(sources.Result{IP: "1.1.1.1", Port: 8443})
+	require.Equal(t, ChangeAdded, change)
+
+	removed := differ.Removed()
+	require.Len(t, removed, 1)
+	require.Equal(t, "2.2.2.2", removed[0].IP)
+	require.Equal(t, ChangeRemoved, removed[0].Change)
+
+	_, err = NewDiffer(&DiffOptions{Previous: "missing/previous.jsonl"})
+	require.NotNil(t, err)
+	_, err = NewDiffer(&DiffOptions{Fields: []string{"banner"}})
+	require.NotNil(t, err)
+}
+
+func TestDifferSnapshot(t *testing.T) {
+	location := DefaultSnapshotLocation
+	DefaultSnapshotLocation = t.TempDir()
+	defer func() { DefaultSnapshotLocation = location }()
+
+	differ, err := NewDiffer(&DiffOptions{Snapshot: "daily"})
+	require.Nil(t, err)
+	change, _ := differ.Compare(sources.Result{IP: "1.1.1.1", Port: 443})
+	require.Equal(t, ChangeAdded, change)
+	require.Nil(t, differ.Save())
+	differ.Close()
+
+	differ, err = NewDiffer(&DiffOptions{Previous: "daily"})
+	require.Nil(t, err)
+	defer differ.Close()
+	_, changed := differ.Compare(sources.Result{IP: "1.1.1.1", Port: 443})
+	require.False(t, changed)
+}
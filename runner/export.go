@@ -0,0 +1,439 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// ExportFormat writes the assets found during a run for another tool
+type ExportFormat func(assets *AssetIndex, writer io.Writer) error
+
+// ExportFormats are the formats supported by -export
+var ExportFormats = map[string]ExportFormat{
+	"nmap":  writeNmapXML,
+	"ports": writePortLists,
+	"urls":  writeURLs,
+	"stix":  writeSTIX,
+}
+
+// Export is a format written to a file once the run completes
+type Export struct {
+	Format string
+	File   string
+}
+
+// ParseExports parses format=file values of -export
+func ParseExports(values []string) ([]Export, error) {
+	var exports []Export
+	for _, value := range values {
+		format, file, ok := strings.Cut(value, "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !ok || strings.TrimSpace(file) == "" {
+			return nil, errorutil.New("invalid export %s, expected format=file", value)
+		}
+		if _, ok := ExportFormats[format]; !ok {
+			return nil, errorutil.New("invalid export format %s, supported formats are %s", format, strings.Join(exportFormatNames(), ","))
+		}
+		exports = append(exports, Export{Format: format, File: strings.TrimSpace(file)})
+	}
+	return exports, nil
+}
+
+// WriteFile writes assets to the file of the export
+func (e Export) WriteFile(assets *AssetIndex) error {
+	file, err := os.Create(e.File)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not create %s export file %s", e.Format, e.File)
+	}
+	defer file.Close()
+	return ExportFormats[e.Format](assets, file)
+}
+
+func exportFormatNames() []string {
+	names := make([]string, 0, len(ExportFormats))
+	for name := range ExportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AssetIndex groups the results of a run by ip, then port
+type AssetIndex struct {
+	hosts map[string]*AssetHost
+	start time.Time
+	sync.Mutex
+}
+
+// AssetHost is an ip, or a host when the engine returned no ip, with its open ports
+type AssetHost struct {
+	IP        string
+	Hostnames []string
+	Ports     map[int]*AssetPort
+	Sources   []string
+	Queries   []string
+	// Pivots are the type:value pivots which led to the host
+	Pivots    []string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// AssetPort is an open port of a host
+type AssetPort struct {
+	Port    int
+	Service string
+	URLs    []string
+	Sources []string
+	Queries []string
+}
+
+// NewAssetIndex creates an empty index
+func NewAssetIndex() *AssetIndex {
+	return &AssetIndex{hosts: make(map[string]*AssetHost), start: time.Now()}
+}
+
+// Add indexes result
+func (a *AssetIndex) Add(result sources.Result) {
+	if result.Error != nil || (result.IP == "" && result.Host == "") {
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+
+	host := strings.ToLower(result.Host)
+	key := result.IP
+	if key == "" {
+		key = "host:" + host
+	}
+	asset, ok := a.hosts[key]
+	if !ok {
+		asset = &AssetHost{IP: result.IP, Ports: make(map[int]*AssetPort)}
+		a.hosts[key] = asset
+	}
+	seen := time.Now()
+	if result.Timestamp > 0 {
+		seen = time.Unix(result.Timestamp, 0)
+	}
+	if asset.FirstSeen.IsZero() || seen.Before(asset.FirstSeen) {
+		asset.FirstSeen = seen
+	}
+	if seen.After(asset.LastSeen) {
+		asset.LastSeen = seen
+	}
+	// engines return the ip itself as host when there is no hostname
+	if host != "" && host != result.IP {
+		asset.Hostnames = appendUnique(asset.Hostnames, host)
+	}
+	asset.Sources = appendUnique(asset.Sources, result.Source)
+	asset.Queries = appendUnique(asset.Queries, result.Query)
+	if result.Pivot != nil {
+		asset.Pivots = appendUnique(asset.Pivots, fmt.Sprintf("%s:%s", result.Pivot.Type, result.Pivot.Value))
+	}
+	if result.Port <= 0 {
+		return
+	}
+	port, ok := asset.Ports[result.Port]
+	if !ok {
+		port = &AssetPort{Port: result.Port, Service: ServiceName(result.Port, result.Url)}
+		asset.Ports[result.Port] = port
+	}
+	if result.Url != "" {
+		port.URLs = appendUnique(port.URLs, result.Url)
+		if port.Service == "" {
+			port.Service = ServiceName(result.Port, result.Url)
+		}
+	}
+	port.Sources = appendUnique(port.Sources, result.Source)
+	port.Queries = appendUnique(port.Queries, result.Query)
+}
+
+// Hosts returns the indexed hosts ordered by ip then hostname
+func (a *AssetIndex) Hosts() []*AssetHost {
+	a.Lock()
+	defer a.Unlock()
+
+	hosts := make([]*AssetHost, 0, len(a.hosts))
+	for _, host := range a.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		ipI, ipJ := net.ParseIP(hosts[i].IP), net.ParseIP(hosts[j].IP)
+		switch {
+		case ipI != nil && ipJ != nil:
+			if c := bytes.Compare(ipI.To16(), ipJ.To16()); c != 0 {
+				return c < 0
+			}
+		case ipI != nil:
+			return true
+		case ipJ != nil:
+			return false
+		}
+		return strings.Join(hosts[i].Hostnames, ",") < strings.Join(hosts[j].Hostnames, ",")
+	})
+	return hosts
+}
+
+// SortedPorts returns the ports of the host in ascending order
+func (h *AssetHost) SortedPorts() []*AssetPort {
+	ports := make([]*AssetPort, 0, len(h.Ports))
+	for _, port := range h.Ports {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Port < ports[j].Port })
+	return ports
+}
+
+// wellKnownServices are the nmap service names of common ports
+var wellKnownServices = map[int]string{
+	21: "ftp", 22: "ssh", 23: "telnet", 25: "smtp", 53: "domain", 80: "http", 110: "pop3",
+	111: "rpcbind", 135: "msrpc", 139: "netbios-ssn", 143: "imap", 161: "snmp", 389: "ldap",
+	443: "https", 445: "microsoft-ds", 465: "smtps", 587: "submission", 636: "ldapssl",
+	873: "rsync", 993: "imaps", 995: "pop3s", 1080: "socks", 1433: "ms-sql-s", 1521: "oracle",
+	2049: "nfs", 2375: "docker", 3306: "mysql", 3389: "ms-wbt-server", 5432: "postgresql",
+	5672: "amqp", 5900: "vnc", 6379: "redis", 8000: "http-alt", 8080: "http-proxy",
+	8443: "https-alt", 8888: "sun-answerbook", 9200: "wap-wsp", 11211: "memcache", 27017: "mongod",
+}
+
+// httpsPorts and httpPorts are the ports whose url scheme is inferred
+var (
+	httpsPorts = map[int]struct{}{443: {}, 4443: {}, 8443: {}, 9443: {}, 10443: {}}
+	httpPorts  = map[int]struct{}{80: {}, 81: {}, 3000: {}, 5000: {}, 8000: {}, 8008: {}, 8080: {}, 8081: {}, 8088: {}, 8888: {}, 9000: {}, 9090: {}}
+)
+
+// ServiceName returns the service of port, the scheme of rawURL wins over the port number
+func ServiceName(port int, rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		return strings.ToLower(parsed.Scheme)
+	}
+	return wellKnownServices[port]
+}
+
+// webScheme returns the url scheme of a port, empty when it is not a web port
+func webScheme(port *AssetPort) string {
+	switch port.Service {
+	case "https", "https-alt":
+		return "https"
+	case "http", "http-alt", "http-proxy":
+		return "http"
+	}
+	if _, ok := httpsPorts[port.Port]; ok {
+		return "https"
+	}
+	if _, ok := httpPorts[port.Port]; ok {
+		return "http"
+	}
+	return ""
+}
+
+type nmapRun struct {
+	XMLName          xml.Name   `xml:"nmaprun"`
+	Scanner          string     `xml:"scanner,attr"`
+	Args             string     `xml:"args,attr"`
+	Start            int64      `xml:"start,attr"`
+	StartStr         string     `xml:"startstr,attr"`
+	Version          string     `xml:"version,attr"`
+	XMLOutputVersion string     `xml:"xmloutputversion,attr"`
+	Hosts            []nmapHost `xml:"host"`
+	RunStats         struct {
+		Finished struct {
+			Time    int64  `xml:"time,attr"`
+			TimeStr string `xml:"timestr,attr"`
+			Exit    string `xml:"exit,attr"`
+		} `xml:"finished"`
+		Hosts struct {
+			Up    int `xml:"up,attr"`
+			Down  int `xml:"down,attr"`
+			Total int `xml:"total,attr"`
+		} `xml:"hosts"`
+	} `xml:"runstats"`
+}
+
+type nmapHost struct {
+	StartTime int64       `xml:"starttime,attr"`
+	EndTime   int64       `xml:"endtime,attr"`
+	Status    nmapState   `xml:"status"`
+	Address   nmapAddress `xml:"address"`
+	Hostnames []nmapName  `xml:"hostnames>hostname"`
+	Ports     []nmapPort  `xml:"ports>port"`
+}
+
+type nmapState struct {
+	State     string `xml:"state,attr"`
+	Reason    string `xml:"reason,attr"`
+	ReasonTTL int    `xml:"reason_ttl,attr"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type nmapName struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type nmapPort struct {
+	Protocol string       `xml:"protocol,attr"`
+	PortID   int          `xml:"portid,attr"`
+	State    nmapState    `xml:"state"`
+	Service  *nmapService `xml:"service,omitempty"`
+}
+
+type nmapService struct {
+	Name   string `xml:"name,attr"`
+	Tunnel string `xml:"tunnel,attr,omitempty"`
+	Method string `xml:"method,attr"`
+	Conf   int    `xml:"conf,attr"`
+}
+
+// writeNmapXML writes the hosts with an ip in the nmap xml output format,
+// the states are user-set as uncover never scanned them
+func writeNmapXML(assets *AssetIndex, writer io.Writer) error {
+	now := time.Now()
+	run := nmapRun{
+		Scanner:          "uncover",
+		Args:             strings.Join(os.Args, " "),
+		Start:            assets.start.Unix(),
+		StartStr:         assets.start.Format(time.ANSIC),
+		Version:          "1.0",
+		XMLOutputVersion: "1.05",
+	}
+	userSet := nmapState{State: "open", Reason: "user-set"}
+	for _, host := range assets.Hosts() {
+		ip := net.ParseIP(host.IP)
+		if ip == nil {
+			continue
+		}
+		entry := nmapHost{
+			StartTime: host.FirstSeen.Unix(),
+			EndTime:   host.LastSeen.Unix(),
+			Status:    nmapState{State: "up", Reason: "user-set"},
+			Address:   nmapAddress{Addr: host.IP, AddrType: "ipv4"},
+		}
+		if ip.To4() == nil {
+			entry.Address.AddrType = "ipv6"
+		}
+		for _, name := range host.Hostnames {
+			entry.Hostnames = append(entry.Hostnames, nmapName{Name: name, Type: "user"})
+		}
+		for _, port := range host.SortedPorts() {
+			nport := nmapPort{Protocol: "tcp", PortID: port.Port, State: userSet}
+			if port.Service != "" {
+				nport.Service = &nmapService{Name: port.Service, Method: "table", Conf: 3}
+				if port.Service == "https" {
+					nport.Service.Name, nport.Service.Tunnel = "http", "ssl"
+				}
+			}
+			entry.Ports = append(entry.Ports, nport)
+		}
+		run.Hosts = append(run.Hosts, entry)
+	}
+	run.RunStats.Finished.Time = now.Unix()
+	run.RunStats.Finished.TimeStr = now.Format(time.ANSIC)
+	run.RunStats.Finished.Exit = "success"
+	run.RunStats.Hosts.Up = len(run.Hosts)
+	run.RunStats.Hosts.Total = len(run.Hosts)
+
+	if _, err := io.WriteString(writer, xml.Header+"<!DOCTYPE nmaprun>\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(run); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+// writePortLists writes one line per ip with its ports, ready for nmap -p
+// (example: 93.184.216.34 80,443)
+func writePortLists(assets *AssetIndex, writer io.Writer) error {
+	for _, host := range assets.Hosts() {
+		if host.IP == "" || len(host.Ports) == 0 {
+			continue
+		}
+		ports := make([]string, 0, len(host.Ports))
+		for _, port := range host.SortedPorts() {
+			ports = append(ports, strconv.Itoa(port.Port))
+		}
+		if _, err := fmt.Fprintf(writer, "%s %s\n", host.IP, strings.Join(ports, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeURLs writes the urls returned by the engines and those of web ports,
+// their scheme inferred from the port, for each hostname or the ip
+func writeURLs(assets *AssetIndex, writer io.Writer) error {
+	seen := make(map[string]struct{})
+	write := func(u string) error {
+		if _, ok := seen[u]; ok {
+			return nil
+		}
+		seen[u] = struct{}{}
+		_, err := fmt.Fprintln(writer, u)
+		return err
+	}
+	for _, host := range assets.Hosts() {
+		names := host.Hostnames
+		if len(names) == 0 {
+			names = []string{host.IP}
+		}
+		for _, port := range host.SortedPorts() {
+			for _, u := range port.URLs {
+				if err := write(u); err != nil {
+					return err
+				}
+			}
+			scheme := webScheme(port)
+			if scheme == "" || len(port.URLs) > 0 {
+				continue
+			}
+			for _, name := range names {
+				if err := write(webURL(scheme, name, port.Port)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// webURL builds the url of host:port, the default port of scheme is omitted
+func webURL(scheme, host string, port int) string {
+	if (scheme == "https" && port == 443) || (scheme == "http" && port == 80) {
+		if strings.Contains(host, ":") {
+			return fmt.Sprintf("%s://[%s]", scheme, host)
+		}
+		return fmt.Sprintf("%s://%s", scheme, host)
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+func appendUnique(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestExports(t *testing.T) {
+	assets := NewAssetIndex()
+	assets.Add(sources.Result{Source: "fofa", IP: "10.0.0.2", Port: 8443, Host: "10.0.0.2"})
+	assets.Add(sources.Result{Source: "fofa", IP: "10.0.0.1", Port: 443, Host: "www.example.com"})
+	assets.Add(sources.Result{Source: "shodan", IP: "10.0.0.1", Port: 22, Host: "www.example.com"})
+	assets.Add(sources.Result{Source: "fofa", IP: "10.0.0.1", Port: 8081, Url: "http://www.example.com:8081/admin"})
+	assets.Add(sources.Result{Source: "anubis-spider", Host: "mail.example.com"})
+
+	var buffer bytes.Buffer
+	require.Nil(t, writePortLists(assets, &buffer))
+	require.Equal(t, "10.0.0.1 22,443,8081\n10.0.0.2 8443\n", buffer.String())
+
+	buffer.Reset()
+	require.Nil(t, writeURLs(assets, &buffer))
+	require.Equal(t, "https://www.example.com\nhttp://www.example.com:8081/admin\nhttps://10.0.0.2:8443\n", buffer.String())
+
+	buffer.Reset()
+	require.Nil(t, writeNmapXML(assets, &buffer))
+	var run nmapRun
+	require.Nil(t, xml.Unmarshal(buffer.Bytes(), &run))
+	require.Len(t, run.Hosts, 2, "hosts without ip are not part of the nmap output")
+	require.Equal(t, "10.0.0.1", run.Hosts[0].Address.Addr)
+	require.Equal(t, []nmapName{{Name: "www.example.com", Type: "user"}}, run.Hosts[0].Hostnames)
+	require.Len(t, run.Hosts[0].Ports, 3)
+	require.Equal(t, "ssh", run.Hosts[0].Ports[0].Service.Name)
+	require.Equal(t, "ssl", run.Hosts[0].Ports[1].Service.Tunnel)
+
+	exports, err := ParseExports([]string{"nmap=scan.xml", "urls = urls.txt"})
+	require.Nil(t, err)
+	require.Equal(t, []Export{{Format: "nmap", File: "scan.xml"}, {Format: "urls", File: "urls.txt"}}, exports)
+	_, err = ParseExports([]string{"masscan=out.txt"})
+	require.NotNil(t, err)
+}
+
+func TestSTIXExport(t *testing.T) {
+	assets := NewAssetIndex()
+	assets.Add(sources.Result{Source: "fofa", Query: "icon_hash=\"-1\"", IP: "10.0.0.1", Port: 443, Host: "c2.example.com", Url: "https://c2.example.com"})
+	assets.Add(sources.Result{Source: "shodan", Query: "ssl:example", IP: "10.0.0.1", Port: 443, Host: "c2.example.com"})
+	assets.Add(sources.Result{Source: "fofa", IP: "2001:db8::1", Port: 22})
+
+	var buffer bytes.Buffer
+	require.Nil(t, writeSTIX(assets, &buffer))
+	var bundle struct {
+		Type    string                   `json:"type"`
+		Objects []map[string]interface{} `json:"objects"`
+	}
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &bundle))
+	require.Equal(t, "bundle", bundle.Type)
+
+	kinds := make(map[string]int)
+	objects := make(map[string]map[string]interface{})
+	for _, object := range bundle.Objects {
+		kinds[object["type"].(string)]++
+		objects[object["id"].(string)] = object
+	}
+	require.Equal(t, map[string]int{"ipv4-addr": 1, "ipv6-addr": 1, "domain-name": 1, "network-traffic": 2, "url": 1, "relationship": 2}, kinds)
+	for _, object := range bundle.Objects {
+		if object["type"] == "ipv4-addr" {
+			require.Equal(t, []interface{}{"fofa", "shodan"}, object["x_uncover_source"])
+			require.Equal(t, []interface{}{"icon_hash=\"-1\"", "ssl:example"}, object["x_uncover_query"])
+		}
+		if object["type"] == "relationship" {
+			require.Contains(t, objects, object["source_ref"])
+			require.Contains(t, objects, object["target_ref"])
+		}
+	}
+
+	var again bytes.Buffer
+	require.Nil(t, writeSTIX(assets, &again))
+	require.Contains(t, again.String(), bundle.Objects[0]["id"].(string), "observable ids are deterministic")
+}
@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/wjlin0/uncover"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// FacetRow is the number of results sharing the value of a field, merged across engines and queries
+type FacetRow struct {
+	Field   string         `json:"field"`
+	Value   string         `json:"value"`
+	Count   int            `json:"count"`
+	Engines map[string]int `json:"engines"`
+	// Sampled is set when some of the counts are estimated from fetched results
+	Sampled bool `json:"sampled,omitempty"`
+}
+
+// FacetTable merges the facets of every engine and query
+type FacetTable struct {
+	// counts are the counts of each engine by value by field
+	counts map[string]map[string]map[string]int
+	// sampled are the values by field with counts from fetched results
+	sampled map[string]map[string]bool
+	sync.Mutex
+}
+
+// NewFacetTable creates an empty facet table
+func NewFacetTable() *FacetTable {
+	return &FacetTable{counts: make(map[string]map[string]map[string]int), sampled: make(map[string]map[string]bool)}
+}
+
+// Add adds the count of facet to the count of its value
+func (t *FacetTable) Add(facet sources.Facet) {
+	if facet.Value == "" || facet.Count <= 0 {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+
+	values, ok := t.counts[facet.Field]
+	if !ok {
+		values = make(map[string]map[string]int)
+		t.counts[facet.Field] = values
+	}
+	engines, ok := values[facet.Value]
+	if !ok {
+		engines = make(map[string]int)
+		values[facet.Value] = engines
+	}
+	engines[facet.Source] += facet.Count
+	if facet.Sampled {
+		if t.sampled[facet.Field] == nil {
+			t.sampled[facet.Field] = make(map[string]bool)
+		}
+		t.sampled[facet.Field][facet.Value] = true
+	}
+}
+
+// Rows returns the merged counts of field, the most common value first
+func (t *FacetTable) Rows(field string) []FacetRow {
+	t.Lock()
+	defer t.Unlock()
+
+	totals := make(map[string]int)
+	for value, engines := range t.counts[field] {
+		for _, count := range engines {
+			totals[value] += count
+		}
+	}
+	var rows []FacetRow
+	for _, facet := range uncover.SortFacets(totals, field) {
+		engines := make(map[string]int)
+		for engine, count := range t.counts[field][facet.Value] {
+			engines[engine] = count
+		}
+		rows = append(rows, FacetRow{Field: field, Value: facet.Value, Count: facet.Count, Engines: engines, Sampled: t.sampled[field][facet.Value]})
+	}
+	return rows
+}
+
+// runFacets counts the results by the -facet fields instead of listing them
+func (r *Runner) runFacets(ctx context.Context) error {
+	table := NewFacetTable()
+	if len(r.options.InputResults) > 0 {
+		// saved results are counted from their fields and raw engine responses
+		err := r.readInputResults(ctx, func(result sources.Result) {
+			if result.Source == "" {
+				result.Source = "unknown"
+			}
+			if !r.filter.Match(result) {
+				return
+			}
+			for _, field := range r.options.Facets {
+				table.Add(sources.Facet{Field: field, Value: sources.FacetValue(result, field), Count: 1, Source: result.Source})
+			}
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		r.service.Options.QueryStream = r.options.queryStream(ctx)
+		r.service.Options.TargetStream = r.options.targetStream(ctx)
+		if err := r.service.ExecuteFacets(ctx, r.options.Facets, table.Add); err != nil {
+			return err
+		}
+	}
+	for _, field := range r.options.Facets {
+		rows := table.Rows(field)
+		if len(rows) == 0 {
+			gologger.Info().Msgf("No %s facet found\n", field)
+			continue
+		}
+		for _, row := range rows {
+			r.writeFacet(row)
+		}
+	}
+	return nil
+}
+
+// writeFacet writes row as json or as field, value, count and per engine counts,
+// sampled counts are prefixed with ~
+func (r *Runner) writeFacet(row FacetRow) {
+	engines := make([]string, 0, len(row.Engines))
+	for engine := range row.Engines {
+		engines = append(engines, engine)
+	}
+	sort.Strings(engines)
+	for i, engine := range engines {
+		engines[i] = engine + ":" + strconv.Itoa(row.Engines[engine])
+	}
+	count := strconv.Itoa(row.Count)
+	if row.Sampled {
+		count = "~" + count
+	}
+	r.writeLine(row, fmt.Sprintf("%s\t%s\t%s\t%s", row.Field, row.Value, count, strings.Join(engines, ",")))
+}
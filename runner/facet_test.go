@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestFacetTable(t *testing.T) {
+	table := NewFacetTable()
+	table.Add(sources.Facet{Field: "port", Value: "443", Count: 10, Source: "fofa"})
+	table.Add(sources.Facet{Field: "port", Value: "443", Count: 5, Source: "shodan"})
+	table.Add(sources.Facet{Field: "port", Value: "80", Count: 15, Source: "shodan"})
+	table.Add(sources.Facet{Field: "port", Value: "22", Count: 3, Source: "fofa", Sampled: true})
+	table.Add(sources.Facet{Field: "port", Value: "", Count: 3, Source: "fofa"})
+
+	require.Equal(t, []FacetRow{
+		{Field: "port", Value: "80", Count: 15, Engines: map[string]int{"shodan": 15}},
+		{Field: "port", Value: "443", Count: 15, Engines: map[string]int{"fofa": 10, "shodan": 5}},
+		{Field: "port", Value: "22", Count: 3, Engines: map[string]int{"fofa": 3}, Sampled: true},
+	}, table.Rows("port"))
+	require.Empty(t, table.Rows("country"))
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/projectdiscovery/gologger"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+	"github.com/wjlin0/uncover/utils"
+)
+
+// faviconTargets returns the mmh3 and md5 hash targets of every -favicon value
+func (r *Runner) faviconTargets() []string {
+	var targets []string
+	for _, location := range r.options.Favicon {
+		data, err := r.readFavicon(location)
+		if err != nil {
+			gologger.Error().Msgf("couldn't read favicon %s: %s\n", location, err)
+			continue
+		}
+		mmh3, md5, err := utils.FaviconHashes(data)
+		if err != nil {
+			gologger.Error().Msgf("couldn't hash favicon %s: %s\n", location, err)
+			continue
+		}
+		gologger.Info().Msgf("favicon %s mmh3: %s md5: %s\n", location, mmh3, md5)
+		targets = append(targets, "mmh3:"+mmh3, md5)
+	}
+	return targets
+}
+
+// readFavicon reads a local icon or fetches it from an url, the
+// default /favicon.ico is used when the url has no path
+func (r *Runner) readFavicon(location string) ([]byte, error) {
+	if fileutil.FileExists(location) {
+		return os.ReadFile(location)
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return nil, errorutil.New("%s is neither a file nor an url", location)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/favicon.ico"
+	}
+	request, err := sources.NewHTTPRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.service.Session.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, errorutil.New("unexpected status code %d received from %s", resp.StatusCode, u.String())
+	}
+	body, err := sources.ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
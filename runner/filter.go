@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"regexp"
+	"strings"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// ResultFilter keeps the results matching every one of its conditions
+type ResultFilter struct {
+	conditions []filterCondition
+}
+
+// filterCondition matches the value of a result field, * being a wildcard
+type filterCondition struct {
+	field   string
+	pattern *regexp.Regexp
+	exclude bool
+}
+
+// ParseResultFilter parses field=value and field!=value expressions, nil is returned
+// without expressions
+func ParseResultFilter(expressions []string) (*ResultFilter, error) {
+	if len(expressions) == 0 {
+		return nil, nil
+	}
+	filter := &ResultFilter{}
+	for _, expression := range expressions {
+		field, value, ok := strings.Cut(expression, "=")
+		if !ok {
+			return nil, errorutil.New("invalid filter %s, filters are field=value or field!=value", expression)
+		}
+		condition := filterCondition{field: strings.ToLower(strings.TrimSpace(field))}
+		if strings.HasSuffix(condition.field, "!") {
+			condition.field, condition.exclude = strings.TrimSpace(strings.TrimSuffix(condition.field, "!")), true
+		}
+		if _, ok := resultField(sources.Result{}, condition.field); !ok {
+			return nil, errorutil.New("invalid filter field %s, supported fields are ip,port,host,url,source", condition.field)
+		}
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSpace(value)), `\*`, ".*")
+		condition.pattern = regexp.MustCompile("(?i)^" + pattern + "$")
+		filter.conditions = append(filter.conditions, condition)
+	}
+	return filter, nil
+}
+
+// Match reports whether result matches the conditions of the filter, a nil filter matches every result
+func (f *ResultFilter) Match(result sources.Result) bool {
+	if f == nil {
+		return true
+	}
+	for _, condition := range f.conditions {
+		value, _ := resultField(result, condition.field)
+		if condition.pattern.MatchString(value) == condition.exclude {
+			return false
+		}
+	}
+	return true
+}
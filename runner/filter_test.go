@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestResultFilter(t *testing.T) {
+	filter, err := ParseResultFilter([]string{"host=*.Example.com", "port!=80"})
+	require.Nil(t, err)
+	require.True(t, filter.Match(sources.Result{Host: "www.example.com", Port: 443}))
+	require.False(t, filter.Match(sources.Result{Host: "www.example.com", Port: 80}))
+	require.False(t, filter.Match(sources.Result{Host: "example.org", Port: 443}))
+
+	var none *ResultFilter
+	require.True(t, none.Match(sources.Result{}))
+	_, err = ParseResultFilter([]string{"title=admin"})
+	require.ErrorContains(t, err, "invalid filter field title")
+	_, err = ParseResultFilter([]string{"host"})
+	require.ErrorContains(t, err, "invalid filter host")
+}
@@ -0,0 +1,302 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	"github.com/wjlin0/uncover/sources"
+	util "github.com/wjlin0/uncover/utils"
+)
+
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatGraphML = "graphml"
+	GraphFormatJSON    = "json"
+)
+
+// node kinds of the asset graph
+const (
+	NodeHost   = "host"
+	NodeIP     = "ip"
+	NodePort   = "port"
+	NodeDomain = "domain"
+	NodeSource = "source"
+	NodeCert   = "cert"
+	NodePivot  = "pivot"
+)
+
+// edge kinds of the asset graph
+const (
+	EdgeResolves   = "resolves_to"
+	EdgeListens    = "listens_on"
+	EdgeSubdomain  = "subdomain_of"
+	EdgeFoundBy    = "found_by"
+	EdgePresents   = "presents"
+	EdgePivotedOn  = "pivoted_on"
+	EdgeDiscovered = "discovered"
+)
+
+// GraphNode is an asset of the graph
+type GraphNode struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+// GraphEdge is a relationship between two assets
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// Graph is the relationship graph of the assets found in results
+type Graph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+
+	nodes map[string]*GraphNode
+	edges map[string]struct{}
+	sync.Mutex
+}
+
+// NewGraph creates an empty graph
+func NewGraph() *Graph {
+	return &Graph{
+		Nodes: []*GraphNode{},
+		Edges: []*GraphEdge{},
+		nodes: make(map[string]*GraphNode),
+		edges: make(map[string]struct{}),
+	}
+}
+
+// Add adds the assets of result and their relationships to the graph
+func (g *Graph) Add(result sources.Result) {
+	if result.Error != nil {
+		return
+	}
+	g.Lock()
+	defer g.Unlock()
+
+	var host, ip, port string
+	if name := strings.ToLower(result.Host); name != "" {
+		host = g.node(NodeHost, name)
+		if apex := util.GetMainDomain(name); util.IsValidDomain(name) && apex != name {
+			g.edge(host, g.node(NodeDomain, apex), EdgeSubdomain)
+		}
+	}
+	if result.IP != "" {
+		ip = g.node(NodeIP, result.IP)
+		if host != "" {
+			g.edge(host, ip, EdgeResolves)
+		}
+		if result.Port > 0 {
+			port = g.node(NodePort, result.IpPort())
+			g.edge(ip, port, EdgeListens)
+		}
+	}
+	asset := firstNonEmpty(port, host, ip)
+	if asset == "" {
+		return
+	}
+	if result.Source != "" {
+		g.edge(asset, g.node(NodeSource, result.Source), EdgeFoundBy)
+	}
+	for _, target := range sources.ExtractPivots(result) {
+		if target.Type == sources.TargetCert {
+			g.edge(asset, g.node(NodeCert, target.Value), EdgePresents)
+		}
+	}
+	if pivot := result.Pivot; pivot != nil {
+		pivotNode := g.node(NodePivot, fmt.Sprintf("%s:%s", pivot.Type, pivot.Value))
+		if pivot.From != "" {
+			g.edge(g.node(g.fromKind(pivot.From), pivot.From), pivotNode, EdgePivotedOn)
+		}
+		g.edge(pivotNode, asset, EdgeDiscovered)
+	}
+}
+
+// fromKind returns the node kind of the pivot origin, either a host or an ip:port
+func (g *Graph) fromKind(from string) string {
+	if h, _, err := net.SplitHostPort(from); err == nil && net.ParseIP(h) != nil {
+		return NodePort
+	}
+	return NodeHost
+}
+
+func (g *Graph) node(kind, label string) string {
+	id := kind + ":" + label
+	if _, ok := g.nodes[id]; !ok {
+		node := &GraphNode{ID: id, Type: kind, Label: label}
+		g.nodes[id] = node
+		g.Nodes = append(g.Nodes, node)
+	}
+	return id
+}
+
+func (g *Graph) edge(source, target, kind string) {
+	key := source + "|" + target + "|" + kind
+	if _, ok := g.edges[key]; ok {
+		return
+	}
+	g.edges[key] = struct{}{}
+	g.Edges = append(g.Edges, &GraphEdge{Source: source, Target: target, Type: kind})
+}
+
+// Write writes the graph to writer in format (dot, graphml or json)
+func (g *Graph) Write(writer io.Writer, format string) error {
+	g.Lock()
+	defer g.Unlock()
+
+	switch format {
+	case GraphFormatDOT:
+		return g.writeDOT(writer)
+	case GraphFormatGraphML:
+		return g.writeGraphML(writer)
+	case GraphFormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(g)
+	default:
+		return errorutil.New("invalid graph format %s, supported formats are dot,graphml,json", format)
+	}
+}
+
+// WriteFile writes the graph to file, the format is guessed from the
+// extension of file when empty
+func (g *Graph) WriteFile(file, format string) error {
+	if format == "" {
+		format = GraphFormat(file)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not create graph file %s", file)
+	}
+	defer f.Close()
+	return g.Write(f, format)
+}
+
+// GraphFormat returns the graph format matching the extension of file, json by default
+func GraphFormat(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".dot", ".gv":
+		return GraphFormatDOT
+	case ".graphml", ".xml":
+		return GraphFormatGraphML
+	default:
+		return GraphFormatJSON
+	}
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// dotShapes are the graphviz shapes of each node kind
+var dotShapes = map[string]string{
+	NodeHost:   "box",
+	NodeIP:     "ellipse",
+	NodePort:   "circle",
+	NodeDomain: "folder",
+	NodeSource: "cylinder",
+	NodeCert:   "note",
+	NodePivot:  "diamond",
+}
+
+func (g *Graph) writeDOT(writer io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("digraph uncover {\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&builder, "  \"%s\" [label=\"%s\", shape=%s, type=\"%s\"];\n", dotEscaper.Replace(node.ID), dotEscaper.Replace(node.Label), dotShapes[node.Type], node.Type)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&builder, "  \"%s\" -> \"%s\" [label=\"%s\"];\n", dotEscaper.Replace(edge.Source), dotEscaper.Replace(edge.Target), edge.Type)
+	}
+	builder.WriteString("}\n")
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string           `xml:"id,attr"`
+		EdgeDefault string           `xml:"edgedefault,attr"`
+		Nodes       []graphMLElement `xml:"node"`
+		Edges       []graphMLElement `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLElement struct {
+	ID     string        `xml:"id,attr,omitempty"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (g *Graph) writeGraphML(writer io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+			{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+			{ID: "relation", For: "edge", AttrName: "type", AttrType: "string"},
+		},
+	}
+	doc.Graph.ID = "uncover"
+	doc.Graph.EdgeDefault = "directed"
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLElement{
+			ID:   node.ID,
+			Data: []graphMLData{{Key: "type", Value: node.Type}, {Key: "label", Value: node.Label}},
+		})
+	}
+	for i, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLElement{
+			ID:     "e" + strconv.Itoa(i),
+			Source: edge.Source,
+			Target: edge.Target,
+			Data:   []graphMLData{{Key: "relation", Value: edge.Type}},
+		})
+	}
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestGraph(t *testing.T) {
+	graph := NewGraph()
+	graph.Add(sources.Result{Source: "fofa", IP: "1.1.1.1", Port: 443, Host: "www.example.com"})
+	graph.Add(sources.Result{Source: "fofa", IP: "1.1.1.1", Port: 443, Host: "www.example.com"})
+	graph.Add(sources.Result{
+		Source: "shodan", IP: "1.1.1.2", Port: 80, Host: "api.example.com",
+		Pivot: &sources.Pivot{Type: sources.TargetDomain, Value: "example.com", Depth: 1, Source: "fofa", From: "www.example.com"},
+	})
+	graph.Add(sources.Result{Source: "fofa", Error: errors.New("quota exceeded")})
+
+	edges := map[string]bool{}
+	for _, edge := range graph.Edges {
+		edges[edge.Source+" "+edge.Type+" "+edge.Target] = true
+	}
+	require.True(t, edges["host:www.example.com resolves_to ip:1.1.1.1"])
+	require.True(t, edges["ip:1.1.1.1 listens_on port:1.1.1.1:443"])
+	require.True(t, edges["host:www.example.com subdomain_of domain:example.com"])
+	require.True(t, edges["port:1.1.1.1:443 found_by source:fofa"])
+	require.True(t, edges["host:www.example.com pivoted_on pivot:domain:example.com"])
+	require.True(t, edges["pivot:domain:example.com discovered port:1.1.1.2:80"])
+	require.Len(t, graph.Edges, 10, "duplicate results must not add edges")
+
+	var buffer bytes.Buffer
+	require.Nil(t, graph.Write(&buffer, GraphFormatJSON))
+	var decoded struct {
+		Nodes []GraphNode `json:"nodes"`
+		Edges []GraphEdge `json:"edges"`
+	}
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	require.Len(t, decoded.Nodes, len(graph.Nodes))
+
+	buffer.Reset()
+	require.Nil(t, graph.Write(&buffer, GraphFormatGraphML))
+	require.Nil(t, xml.Unmarshal(buffer.Bytes(), new(graphML)))
+
+	buffer.Reset()
+	require.Nil(t, graph.Write(&buffer, GraphFormatDOT))
+	require.Contains(t, buffer.String(), `"host:www.example.com" -> "ip:1.1.1.1" [label="resolves_to"];`)
+
+	require.NotNil(t, graph.Write(&buffer, "png"))
+	require.Equal(t, GraphFormatDOT, GraphFormat("assets.gv"))
+	require.Equal(t, GraphFormatJSON, GraphFormat("assets"))
+}
@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/projectdiscovery/goflags"
+	"github.com/projectdiscovery/gologger"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// queryStringSliceOptions keeps -q values as given, files are
+// streamed later instead of being loaded while parsing flags
+var queryStringSliceOptions = goflags.Options{
+	IsEmpty: func(s string) bool {
+		return strings.TrimSpace(s) == ""
+	},
+	Normalize: strings.TrimSpace,
+	IsRaw: func(s string) bool {
+		return true
+	},
+}
+
+// stdinTarget is the -target value reading targets from stdin
+const stdinTarget = "-"
+
+// queryStream lazily reads queries from the -q values and stdin.
+func (options *Options) queryStream(ctx context.Context) <-chan string {
+	return streamInput(ctx, options.Query, options.Stdin && !options.targetsFromStdin())
+}
+
+// targetStream lazily reads targets from the -t values and stdin (-t -).
+func (options *Options) targetStream(ctx context.Context) <-chan string {
+	var targets []string
+	for _, target := range options.Target {
+		if target != stdinTarget {
+			targets = append(targets, target)
+		}
+	}
+	return streamInput(ctx, targets, options.Stdin && options.targetsFromStdin())
+}
+
+func (options *Options) targetsFromStdin() bool {
+	for _, target := range options.Target {
+		if target == stdinTarget {
+			return true
+		}
+	}
+	return false
+}
+
+// streamInput lazily reads values followed by stdin when stdin is true.
+// Values pointing to an existing file are read line by line so that
+// huge lists start right away and are never held in memory.
+func streamInput(ctx context.Context, values []string, stdin bool) <-chan string {
+	queries := make(chan string)
+	go func() {
+		defer close(queries)
+		for _, query := range values {
+			if !fileutil.FileExists(query) {
+				if !sendQuery(ctx, queries, query) {
+					return
+				}
+				continue
+			}
+			file, err := os.Open(query)
+			if err != nil {
+				gologger.Error().Msgf("couldn't read query file %s: %s\n", query, err)
+				continue
+			}
+			ok := readQueries(ctx, file, queries)
+			_ = file.Close()
+			if !ok {
+				return
+			}
+		}
+		if stdin {
+			readQueries(ctx, os.Stdin, queries)
+		}
+	}()
+	return queries
+}
+
+// readQueries sends every non-empty line of reader to queries
+func readQueries(ctx context.Context, reader io.Reader, queries chan<- string) bool {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !sendQuery(ctx, queries, scanner.Text()) {
+			return false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		gologger.Error().Msgf("couldn't read queries: %s\n", err)
+	}
+	return true
+}
+
+func sendQuery(ctx context.Context, queries chan<- string, query string) bool {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case queries <- query:
+		return true
+	}
+}
+
+// readInputResults decodes the results of the -input-results files, or stdin
+// for -, and passes those in -scope and seen in -since/-until to callback without querying any engine
+func (r *Runner) readInputResults(ctx context.Context, callback func(sources.Result)) error {
+	scope := uncover.NewScope(r.options.Scope)
+	for _, input := range r.options.InputResults {
+		reader, name := io.Reader(os.Stdin), "stdin"
+		var file *os.File
+		if input != stdinTarget {
+			var err error
+			if file, err = os.Open(input); err != nil {
+				return errorutil.NewWithErr(err).Msgf("could not open input results %s", input)
+			}
+			reader, name = file, input
+		}
+		decoder := sources.NewResultDecoder(reader)
+		count := 0
+		for ctx.Err() == nil {
+			result, err := decoder.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				gologger.Warning().Msgf("Skipping result of %s: %s\n", name, err)
+				continue
+			}
+			if !scope.ContainsResult(result) || !sources.InTimeRange(result, r.options.since, r.options.until) {
+				continue
+			}
+			callback(result)
+			count++
+		}
+		if file != nil {
+			file.Close()
+		}
+		gologger.Verbose().Msgf("Read %d results from %s\n", count, name)
+	}
+	return nil
+}
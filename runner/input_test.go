@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// lineReader generates up to lines queries, counting the ones read so far
+type lineReader struct {
+	lines int
+	read  int
+	buf   []byte
+}
+
+func (reader *lineReader) Read(p []byte) (int, error) {
+	for len(reader.buf) < len(p) && reader.read < reader.lines {
+		reader.read++
+		reader.buf = append(reader.buf, fmt.Sprintf("title=\"query %d\"\n", reader.read)...)
+	}
+	if len(reader.buf) == 0 {
+		return 0, fmt.Errorf("read past the end")
+	}
+	n := copy(p, reader.buf)
+	reader.buf = reader.buf[n:]
+	return n, nil
+}
+
+func TestReadQueriesIncrementally(t *testing.T) {
+	reader := &lineReader{lines: 10_000_000}
+	ctx, cancel := context.WithCancel(context.Background())
+	queries := make(chan string)
+	done := make(chan bool)
+	go func() {
+		done <- readQueries(ctx, reader, queries)
+	}()
+	for i := 1; i <= 3; i++ {
+		require.Equal(t, fmt.Sprintf("title=\"query %d\"", i), <-queries)
+	}
+	cancel()
+	require.False(t, <-done, "a cancelled read stops")
+	// only the buffer of the scanner was read ahead of the queries sent
+	require.Less(t, reader.read, 100_000)
+}
+
+func TestStreamInput(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "queries.txt")
+	require.Nil(t, os.WriteFile(small, []byte("port:22\n\n  port:80  \n"), 0644))
+	large := filepath.Join(dir, "large.txt")
+	require.Nil(t, os.WriteFile(large, []byte(strings.Repeat("port:443\n", 100_000)), 0644))
+
+	var queries []string
+	for query := range streamInput(context.Background(), []string{"ssl:example.com", small, " "}, false) {
+		queries = append(queries, query)
+	}
+	require.Equal(t, []string{"ssl:example.com", "port:22", "port:80"}, queries)
+	requireClosed(t, small)
+
+	// the file of a cancelled stream is closed too
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := streamInput(ctx, []string{large}, false)
+	require.Equal(t, "port:443", <-stream)
+	cancel()
+	for range stream {
+	}
+	requireClosed(t, large)
+}
+
+func TestQueryAndTargetStreams(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	require.Nil(t, err)
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() {
+		os.Stdin = stdin
+		reader.Close()
+	}()
+	_, err = writer.WriteString("10.0.0.0/24\n")
+	require.Nil(t, err)
+	writer.Close()
+
+	options := &Options{Query: []string{"port:22"}, Target: []string{"example.com", stdinTarget}, Stdin: true}
+	var queries, targets []string
+	for query := range options.queryStream(context.Background()) {
+		queries = append(queries, query)
+	}
+	for target := range options.targetStream(context.Background()) {
+		targets = append(targets, target)
+	}
+	// stdin holds targets with -t -, queries otherwise
+	require.Equal(t, []string{"port:22"}, queries)
+	require.Equal(t, []string{"example.com", "10.0.0.0/24"}, targets)
+}
+
+// requireClosed fails when the process still has path open, where open files are listed
+func requireClosed(t *testing.T, path string) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open files aren't listed on this system")
+	}
+	for _, fd := range fds {
+		if target, _ := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); target == path {
+			t.Fatalf("%s is still open", path)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"github.com/projectdiscovery/goflags"
+	"github.com/projectdiscovery/gologger"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// MigrateOptions are the options of the migrate command rewriting provider configs
+type MigrateOptions struct {
+	ProviderFile string
+	Silent       bool
+	NoColor      bool
+}
+
+// ParseMigrateOptions parses the flags of the migrate command, os.Args must hold the
+// command flags without the migrate argument
+func ParseMigrateOptions() *MigrateOptions {
+	options := &MigrateOptions{}
+	flagSet := goflags.NewFlagSet()
+	flagSet.SetDescription(`rewrite a provider config to the latest version, the original file is kept with a .bak extension.`)
+
+	flagSet.CreateGroup("config", "Config",
+		flagSet.StringVarP(&options.ProviderFile, "provider", "pc", sources.DefaultProviderConfigLocation, "provider configuration file to migrate"),
+		flagSet.BoolVar(&options.Silent, "silent", false, "show only errors in output"),
+		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable colors in output"),
+	)
+
+	if err := flagSet.Parse(); err != nil {
+		gologger.Fatal().Msgf("Program exiting: %s\n", err)
+	}
+	(&Options{Silent: options.Silent, NoColor: options.NoColor}).configureOutput()
+	return options
+}
+
+// RunMigrate rewrites the provider config of options to the latest version
+func RunMigrate(options *MigrateOptions) error {
+	migrated, err := sources.MigrateProviderConfig(options.ProviderFile)
+	if err != nil {
+		return err
+	}
+	if !migrated {
+		gologger.Info().Msgf("%s already is a version %d provider config\n", options.ProviderFile, sources.ProviderConfigVersion)
+		return nil
+	}
+	gologger.Info().Msgf("Migrated %s to version %d, the original file is %s.bak\n", options.ProviderFile, sources.ProviderConfigVersion, options.ProviderFile)
+	return nil
+}
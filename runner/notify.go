@@ -0,0 +1,258 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+)
+
+const (
+	WebhookGeneric  = "generic"
+	WebhookSlack    = "slack"
+	WebhookDingTalk = "dingtalk"
+	WebhookFeishu   = "feishu"
+	WebhookWeCom    = "wecom"
+)
+
+var (
+	// DefaultNotifyTemplate is the text template of notification messages
+	DefaultNotifyTemplate = `uncover found {{.Summary}}
+{{range .Results}}[{{.Change}}] {{if .IP}}{{.IP}}:{{.Port}} {{end}}{{.Host}} ({{.Source}})
+{{end}}`
+	// DefaultNotifyBatchSize is the number of results sent per message
+	DefaultNotifyBatchSize = 50
+	// webhookHosts identifies chat webhooks from their url
+	webhookHosts = map[string]string{
+		"hooks.slack.com":     WebhookSlack,
+		"oapi.dingtalk.com":   WebhookDingTalk,
+		"open.feishu.cn":      WebhookFeishu,
+		"open.larksuite.com":  WebhookFeishu,
+		"qyapi.weixin.qq.com": WebhookWeCom,
+	}
+)
+
+// NotifyOptions configures the webhook notifications
+type NotifyOptions struct {
+	// Webhooks are urls, optionally prefixed by their kind (example: slack=https://hooks.slack.com/...)
+	Webhooks []string
+	// Template is a text/template file or inline template rendering the message
+	Template string
+	// BatchSize is the maximum number of results per message
+	BatchSize int
+	Retries   int
+	Timeout   time.Duration
+	// RetryWait is the minimum time waited before retrying a failed message
+	RetryWait time.Duration
+}
+
+// Webhook is a notification endpoint
+type Webhook struct {
+	Kind string
+	URL  string
+}
+
+// Notifier batches results and sends them to webhooks
+type Notifier struct {
+	webhooks  []Webhook
+	template  *template.Template
+	batchSize int
+	client    *retryablehttp.Client
+	pending   []sources.Result
+	sync.Mutex
+}
+
+// notifyMessage is the data given to the message template
+type notifyMessage struct {
+	Results []sources.Result
+}
+
+// Summary returns the number of results of each change kind, like "2 new assets, 1 removed asset"
+func (m notifyMessage) Summary() string {
+	counts := make(map[string]int)
+	for _, result := range m.Results {
+		change := result.Change
+		if change == "" {
+			change = ChangeAdded
+		}
+		counts[change]++
+	}
+	var parts []string
+	for _, kind := range []struct{ change, label string }{
+		{ChangeAdded, "new"},
+		{ChangeRemoved, "removed"},
+		{ChangeChanged, "changed"},
+	} {
+		count := counts[kind.change]
+		if count == 0 {
+			continue
+		}
+		noun := "assets"
+		if count == 1 {
+			noun = "asset"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s %s", count, kind.label, noun))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NewNotifier creates a notifier from options
+func NewNotifier(options *NotifyOptions) (*Notifier, error) {
+	n := &Notifier{batchSize: options.BatchSize}
+	if n.batchSize <= 0 {
+		n.batchSize = DefaultNotifyBatchSize
+	}
+	for _, value := range options.Webhooks {
+		webhook, err := ParseWebhook(value)
+		if err != nil {
+			return nil, err
+		}
+		n.webhooks = append(n.webhooks, webhook)
+	}
+
+	text := options.Template
+	if text == "" {
+		text = DefaultNotifyTemplate
+	} else if fileutil.FileExists(text) {
+		data, err := os.ReadFile(text)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	var err error
+	if n.template, err = template.New("notify").Parse(text); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid notification template")
+	}
+
+	clientOptions := retryablehttp.DefaultOptionsSingle
+	clientOptions.RetryMax = options.Retries
+	if options.Timeout > 0 {
+		clientOptions.Timeout = options.Timeout
+	}
+	if options.RetryWait > 0 {
+		clientOptions.RetryWaitMin = options.RetryWait
+		clientOptions.RetryWaitMax = options.RetryWait
+	}
+	clientOptions.CheckRetry = retryOnServerError
+	n.client = retryablehttp.NewClient(clientOptions)
+	return n, nil
+}
+
+// retryOnServerError also retries the requests rejected by a rate limit or a server error
+func retryOnServerError(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if err == nil && resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) {
+		return ctx.Err() == nil, ctx.Err()
+	}
+	return retryablehttp.CheckRecoverableErrors(ctx, resp, err)
+}
+
+// ParseWebhook parses a webhook url optionally prefixed by its kind,
+// the kind of chat webhooks is otherwise guessed from their host
+func ParseWebhook(value string) (Webhook, error) {
+	webhook := Webhook{Kind: WebhookGeneric, URL: strings.TrimSpace(value)}
+	if kind, rawURL, ok := strings.Cut(webhook.URL, "="); ok && !strings.Contains(kind, "/") {
+		switch kind {
+		case WebhookGeneric, WebhookSlack, WebhookDingTalk, WebhookFeishu, WebhookWeCom:
+			return Webhook{Kind: kind, URL: rawURL}, nil
+		default:
+			return webhook, errorutil.New("invalid webhook kind %s, supported kinds are generic,slack,dingtalk,feishu,wecom", kind)
+		}
+	}
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || parsed.Host == "" {
+		return webhook, errorutil.New("invalid webhook url %s", webhook.URL)
+	}
+	if kind, ok := webhookHosts[parsed.Hostname()]; ok {
+		webhook.Kind = kind
+	}
+	return webhook, nil
+}
+
+// Add queues result, a batch is sent as soon as it is full
+func (n *Notifier) Add(result sources.Result) {
+	n.Lock()
+	n.pending = append(n.pending, result)
+	var batch []sources.Result
+	if len(n.pending) >= n.batchSize {
+		batch, n.pending = n.pending, nil
+	}
+	n.Unlock()
+	if batch != nil {
+		n.send(batch)
+	}
+}
+
+// Flush sends the queued results
+func (n *Notifier) Flush() {
+	n.Lock()
+	batch := n.pending
+	n.pending = nil
+	n.Unlock()
+	if len(batch) > 0 {
+		n.send(batch)
+	}
+}
+
+func (n *Notifier) send(batch []sources.Result) {
+	var text bytes.Buffer
+	if err := n.template.Execute(&text, notifyMessage{Results: batch}); err != nil {
+		gologger.Error().Msgf("Could not render notification: %s\n", err)
+		return
+	}
+	for _, webhook := range n.webhooks {
+		if err := n.post(webhook, webhookBody(webhook.Kind, text.String(), batch)); err != nil {
+			gologger.Error().Msgf("Could not notify %s webhook: %s\n", webhook.Kind, err)
+		}
+	}
+}
+
+func (n *Notifier) post(webhook Webhook, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	request, err := retryablehttp.NewRequest(http.MethodPost, webhook.URL, data)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return errorutil.New("unexpected status code %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// webhookBody wraps text in the message format of kind
+func webhookBody(kind, text string, batch []sources.Result) interface{} {
+	switch kind {
+	case WebhookSlack:
+		return map[string]interface{}{"text": text}
+	case WebhookDingTalk, WebhookWeCom:
+		return map[string]interface{}{"msgtype": "text", "text": map[string]string{"content": text}}
+	case WebhookFeishu:
+		return map[string]interface{}{"msg_type": "text", "content": map[string]string{"text": text}}
+	default:
+		return map[string]interface{}{"text": text, "results": batch}
+	}
+}
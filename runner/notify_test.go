@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestParseWebhook(t *testing.T) {
+	webhook, err := ParseWebhook("https://oapi.dingtalk.com/robot/send?access_token=x")
+	require.Nil(t, err)
+	require.Equal(t, WebhookDingTalk, webhook.Kind)
+
+	webhook, err = ParseWebhook("slack=http://127.0.0.1:8080/hook")
+	require.Nil(t, err)
+	require.Equal(t, Webhook{Kind: WebhookSlack, URL: "http://127.0.0.1:8080/hook"}, webhook)
+
+	webhook, err = ParseWebhook("https://example.com/hook?a=b")
+	require.Nil(t, err)
+	require.Equal(t, WebhookGeneric, webhook.Kind)
+
+	_, err = ParseWebhook("teams=https://example.com/hook")
+	require.NotNil(t, err)
+}
+
+func TestNotifier(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		messages []map[string]interface{}
+		failed   bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		// the first message is rejected once to exercise the retry
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var message map[string]interface{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&message))
+		messages = append(messages, message)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(&NotifyOptions{
+		Webhooks:  []string{"dingtalk=" + server.URL},
+		Template:  "{{range .Results}}{{.Change}} {{.IP}}:{{.Port}};{{end}}",
+		BatchSize: 2,
+		Retries:   2,
+		RetryWait: time.Millisecond,
+	})
+	require.Nil(t, err)
+
+	notifier.Add(sources.Result{IP: "1.1.1.1", Port: 80, Change: ChangeAdded})
+	notifier.Add(sources.Result{IP: "1.1.1.1", Port: 443, Change: ChangeAdded})
+	notifier.Add(sources.Result{IP: "2.2.2.2", Port: 22, Change: ChangeChanged})
+	notifier.Flush()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Len(t, messages, 2)
+	require.Equal(t, "text", messages[0]["msgtype"])
+	require.Equal(t, "added 1.1.1.1:80;added 1.1.1.1:443;", messages[0]["text"].(map[string]interface{})["content"])
+	require.Equal(t, "changed 2.2.2.2:22;", messages[1]["text"].(map[string]interface{})["content"])
+}
+
+func TestNotifySummary(t *testing.T) {
+	message := notifyMessage{Results: []sources.Result{
+		{IP: "1.1.1.1", Change: ChangeAdded},
+		{IP: "1.1.1.2", Change: ChangeAdded},
+		{IP: "2.2.2.2", Change: ChangeRemoved},
+	}}
+	require.Equal(t, "2 new assets, 1 removed asset", message.Summary())
+	message = notifyMessage{Results: []sources.Result{{IP: "2.2.2.2", Change: ChangeChanged}}}
+	require.Equal(t, "1 changed asset", message.Summary())
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"github.com/wjlin0/uncover"
 	"github.com/wjlin0/uncover/utils/update"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"errors"
 
@@ -24,12 +26,28 @@ import (
 var (
 	// cli flags config file location
 	defaultConfigLocation = filepath.Join(folderutil.AppConfigDirOrDefault(".uncover-config", "uncover"), "config.yaml")
+	// DefaultResumeLocation where the state of the runs of each output file is stored
+	DefaultResumeLocation = filepath.Join(sources.UncoverConfigDir, "resume")
+	// DefaultSnapshotLocation where named snapshots of runs are stored
+	DefaultSnapshotLocation = filepath.Join(sources.UncoverConfigDir, "snapshots")
+	// DefaultWatchSnapshot is the snapshot keeping the state of watch mode when -snapshot is not given
+	DefaultWatchSnapshot = "watch"
+	// DefaultDBLocation is the sqlite database used by -db and the db command
+	DefaultDBLocation = filepath.Join(sources.UncoverConfigDir, "uncover.db")
+	// DefaultSinkSpoolLocation keeps the batches the sink could not post
+	DefaultSinkSpoolLocation = filepath.Join(sources.UncoverConfigDir, "spool")
 )
 
 // Options contains the configuration options for tuning the enumeration process.
 type Options struct {
 	Query             goflags.StringSlice
+	Target            goflags.StringSlice
+	Favicon           goflags.StringSlice
+	Cert              goflags.StringSlice
 	Engine            goflags.StringSlice
+	EngineOptions     goflags.StringSlice
+	DisableSharding   bool
+	InputResults      goflags.StringSlice
 	ConfigFile        string
 	ProviderFile      string
 	OutputFile        string
@@ -37,10 +55,45 @@ type Options struct {
 	JSON              bool
 	Raw               bool
 	Limit             int
+	Since             string
+	Until             string
+	Filters           goflags.StringSlice
+	Facets            goflags.StringSlice
+	Count             bool
+	DryRun            bool
+	DedupeFields      goflags.StringSlice
+	DedupeBackend     string
+	Resume            bool
+	GraphFile         string
+	GraphFormat       string
+	Exports           goflags.StringSlice
+	Report            string
+	ReportFile        string
+	Diff              string
+	Snapshot          string
+	DiffFields        goflags.StringSlice
+	DB                string
+	Sink              string
+	SinkFormat        string
+	SinkHeaders       goflags.StringSlice
+	SinkAuth          string
+	SinkGzip          bool
+	SinkBatch         int
+	SinkInterval      time.Duration
+	SinkSpool         string
+	Watch             time.Duration
+	Webhooks          goflags.StringSlice
+	NotifyTemplate    string
+	NotifyBatch       int
 	Silent            bool
 	Verbose           bool
 	NoColor           bool
 	Timeout           int
+	Concurrency       int
+	Recursive         bool
+	Depth             int
+	PivotBudget       int
+	Scope             goflags.StringSlice
 	RateLimit         int
 	RateLimitMinute   int
 	Retries           int
@@ -73,9 +126,26 @@ type Options struct {
 	YahooSpider       goflags.StringSlice
 	ZoomEyeSpider     goflags.StringSlice
 
+	// Stdin is true when queries are piped through stdin
+	Stdin bool
+	// since and until are the parsed -since and -until times
+	since, until time.Time
+	// engineOptions are the parsed -engine-option values by engine
+	engineOptions map[string]map[string]string
+	// proxies and proxyRoutes are the parsed -proxy-list and -proxy-route values
+	proxies     []string
+	proxyRoutes map[string]string
+	// cookieFiles are the parsed -cookie-file values by agent
+	cookieFiles map[string]string
+
 	DisableUpdateCheck bool
 	Proxy              string
 	ProxyAuth          string
+	ProxyList          string
+	ProxyRotation      string
+	ProxyCheckURL      string
+	ProxyRoutes        goflags.StringSlice
+	CookieFiles        goflags.StringSlice
 	Location           string
 }
 
@@ -86,8 +156,19 @@ func ParseOptions() *Options {
 	flagSet.SetDescription(`quickly discover exposed assets on the internet using multiple search engines.`)
 
 	flagSet.CreateGroup("input", "Input",
-		flagSet.StringSliceVarP(&options.Query, "query", "q", nil, "search query, supports: stdin,file,config input (example: -q 'example query', -q 'query.txt')", goflags.FileStringSliceOptions),
+		flagSet.StringSliceVarP(&options.Query, "query", "q", nil, "search query, supports: stdin,file,config input (example: -q 'example query', -q 'query.txt')", queryStringSliceOptions),
+		flagSet.StringSliceVarP(&options.Target, "target", "t", nil, "target (domain,ip,cidr,asn,favicon/cert hash) sent only to engines supporting it, supports: stdin(-t -),file input (example: -t example.com, -t 'targets.txt')", queryStringSliceOptions),
+		flagSet.StringSliceVarP(&options.Favicon, "favicon", "fav", nil, "favicon url or file to pivot on by its mmh3/md5 hash (example: -favicon https://example.com/favicon.ico, -favicon favicon.ico)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringSliceVar(&options.Cert, "cert", nil, "pem file, host:port or sha1/sha256 fingerprint of a tls certificate to pivot on (example: -cert example.com:443, -cert cert.pem)", goflags.CommaSeparatedStringSliceOptions),
 		flagSet.StringSliceVarP(&options.Engine, "engine", "e", nil, fmt.Sprintf("search engine to query %v (default fofa)", uncover.AllAgents()), goflags.FileNormalizedStringSliceOptions),
+		flagSet.StringSliceVarP(&options.InputResults, "input-results", "ir", nil, "uncover jsonl/csv or other tools output (json lines, urls, host:port) to process instead of querying engines, supports: stdin(-ir -) (example: -ir old.jsonl,httpx.json)", goflags.CommaSeparatedStringSliceOptions),
+	)
+
+	flagSet.CreateGroup("pivot", "Pivot",
+		flagSet.BoolVarP(&options.Recursive, "recursive", "rec", false, "feed pivots found in results (apex domains, /24s, certificate fingerprints and orgs, favicon hashes) back as new targets"),
+		flagSet.IntVar(&options.Depth, "depth", 2, "maximum pivot depth of recursive mode"),
+		flagSet.IntVarP(&options.PivotBudget, "pivot-budget", "pb", 100, "maximum number of pivots queried per depth"),
+		flagSet.StringSliceVar(&options.Scope, "scope", nil, "domains, ips and cidrs pivots are restricted to (example: -scope example.com,10.0.0.0/8)", goflags.FileCommaSeparatedStringSliceOptions),
 	)
 
 	flagSet.CreateGroup("search-engine", "Search-Engine",
@@ -125,11 +206,19 @@ func ParseOptions() *Options {
 		flagSet.StringVarP(&options.ProviderFile, "provider", "pc", sources.DefaultProviderConfigLocation, "provider configuration file"),
 		flagSet.StringVar(&options.ConfigFile, "config", defaultConfigLocation, "flag configuration file"),
 		flagSet.IntVar(&options.Timeout, "timeout", 30, "timeout in seconds"),
+		flagSet.IntVarP(&options.Concurrency, "concurrency", "c", 10, "number of concurrent queries to process"),
 		flagSet.IntVarP(&options.RateLimit, "rate-limit", "rl", 0, "maximum number of http requests to send per second"),
 		flagSet.IntVarP(&options.RateLimitMinute, "rate-limit-minute", "rlm", 0, "maximum number of requests to send per minute"),
 		flagSet.IntVar(&options.Retries, "retry", 2, "number of times to retry a failed request"),
 		flagSet.StringVar(&options.Proxy, "proxy", "", "proxy to use for requests (example: http://localhost:1080"),
 		flagSet.StringVar(&options.ProxyAuth, "proxy-auth", "", "proxy authentication in the format username:password"),
+		flagSet.StringVarP(&options.ProxyList, "proxy-list", "pl", "", "file of http/socks5 proxies rotated over, one per line"),
+		flagSet.StringVarP(&options.ProxyRotation, "proxy-rotation", "pr", sources.RotateRequest, "proxy list rotation (request,agent)"),
+		flagSet.StringVarP(&options.ProxyCheckURL, "proxy-check-url", "pcu", sources.DefaultProxyCheckURL, "url requested through each proxy of the proxy list to check it is alive"),
+		flagSet.StringSliceVar(&options.ProxyRoutes, "proxy-route", nil, "engines sent direct or through the proxy list, * for the others (example: -proxy-route '*=direct,google-spider=pool,bing-spider=pool')", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringSliceVar(&options.CookieFiles, "cookie-file", nil, "cookies.txt, JSON cookies or token of the web account of a spider agent as agent=file (example: -cookie-file fofa-spider=cookies.txt)", goflags.StringSliceOptions),
+		flagSet.BoolVarP(&options.DisableSharding, "disable-sharding", "dsh", false, "disable splitting queries over the result cap of engines (10000 on fofa, hunter, quake, zoomeye) by country, port, time and asn"),
+		flagSet.StringSliceVarP(&options.EngineOptions, "engine-option", "eo", nil, "engine specific option as engine.key=value, repeat the flag for several options (example: -eo hunter.is_web=1 -eo fofa.fields=title,country)", goflags.StringSliceOptions),
 	)
 
 	flagSet.CreateGroup("update", "Update",
@@ -143,9 +232,45 @@ func ParseOptions() *Options {
 		flagSet.BoolVarP(&options.JSON, "json", "j", false, "write output in JSONL(ines) format"),
 		flagSet.BoolVarP(&options.Raw, "raw", "r", false, "write raw output as received by the remote api"),
 		flagSet.IntVarP(&options.Limit, "limit", "l", 100, "limit the number of results to return"),
+		flagSet.StringVar(&options.Since, "since", "", "only return results seen after this date, datetime or duration ago (example: -since 30d, -since 2026-01-01)"),
+		flagSet.StringVar(&options.Until, "until", "", "only return results seen before this date, datetime or duration ago (example: -until 2026-01-01)"),
+		flagSet.StringSliceVar(&options.Filters, "filter", nil, "only keep results whose field matches value, * is a wildcard and != excludes (ip,port,host,url,source) (example: -filter 'host=*.example.com,port!=80')", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringSliceVar(&options.Facets, "facet", nil, "count results by port,country,product,org,title,asn with the aggregation api of engines having one, instead of listing them (example: -facet port,country)", goflags.NormalizedStringSliceOptions),
+		flagSet.BoolVar(&options.Count, "count", false, "only fetch the total number of results of each query per engine"),
+		flagSet.BoolVar(&options.DryRun, "dry-run", false, "print the translated queries, the pages fetched under -limit and their estimated credits without querying the engines"),
+		flagSet.StringSliceVarP(&options.DedupeFields, "dedupe-key", "dk", nil, "result fields used to drop duplicates in json/csv output (ip,port,host,url,source) (default ip,port,host)", goflags.NormalizedStringSliceOptions),
+		flagSet.StringVar(&options.DedupeBackend, "dedupe-backend", DedupeBackendAuto, "store used to drop duplicates (auto,memory,disk,bloom)"),
+		flagSet.BoolVar(&options.Resume, "resume", false, "keep the state of the run until it finishes, continuing the interrupted run of the output file if any by appending to it and skipping the finished queries and the results already written"),
+		flagSet.StringVarP(&options.GraphFile, "graph", "g", "", "file to write the asset relationship graph to (example: -graph assets.dot)"),
+		flagSet.StringVarP(&options.GraphFormat, "graph-format", "gf", "", "format of the graph (dot,graphml,json) (default guessed from the graph file extension)"),
+		flagSet.StringSliceVarP(&options.Exports, "export", "ex", nil, "export results for other tools as format=file (nmap,ports,urls,stix) (example: -export nmap=scan.xml,stix=bundle.json)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringVarP(&options.Report, "report", "rp", "", "write a report of the results grouped by query, engine, domain and port (xlsx,html,md)"),
+		flagSet.StringVarP(&options.ReportFile, "report-output", "rpo", "", "file to write the report to (default uncover-report.<format>)"),
+		flagSet.StringVar(&options.Diff, "diff", "", "jsonl file or snapshot name of a previous run, only added, removed and changed results are written (example: -diff previous.jsonl)"),
+		flagSet.StringVar(&options.Snapshot, "snapshot", "", "name under which the results are stored for later diffs, compared with when -diff is not given (example: -snapshot daily)"),
+		flagSet.StringSliceVarP(&options.DiffFields, "diff-key", "dfk", nil, "result fields identifying a record between runs (ip,port,host,url,source) (default ip,port)", goflags.NormalizedStringSliceOptions),
+		flagSet.DynamicVar(&options.DB, "db", DefaultDBLocation, "sqlite database recording every result with its run, query and time, searched with 'uncover db' (example: -db, -db=uncover.db)"),
 		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable colors in output"),
 	)
 
+	flagSet.CreateGroup("sink", "Sink",
+		flagSet.StringVar(&options.Sink, "sink", "", "http endpoint results are posted to in batches (example: -sink https://collector.example.com/ingest)"),
+		flagSet.StringVarP(&options.SinkFormat, "sink-format", "sf", SinkFormatNDJSON, "format of the posted batches (ndjson,json)"),
+		flagSet.StringSliceVarP(&options.SinkHeaders, "sink-header", "sh", nil, "header sent to the sink (example: -sink-header 'X-Api-Key: key')", goflags.StringSliceOptions),
+		flagSet.StringVarP(&options.SinkAuth, "sink-auth", "sa", "", "sink authentication as user:pass or authorization header value (example: -sink-auth 'Bearer token')"),
+		flagSet.BoolVarP(&options.SinkGzip, "sink-gzip", "sz", false, "gzip the posted batches"),
+		flagSet.IntVarP(&options.SinkBatch, "sink-batch", "sb", 100, "maximum number of results per posted batch"),
+		flagSet.DurationVarP(&options.SinkInterval, "sink-interval", "si", 10*time.Second, "maximum time a result waits before being posted"),
+		flagSet.StringVarP(&options.SinkSpool, "sink-spool", "ss", DefaultSinkSpoolLocation, "folder keeping the batches which could not be posted or did not fit in the queue until they are posted"),
+	)
+
+	flagSet.CreateGroup("watch", "Watch",
+		flagSet.DurationVar(&options.Watch, "watch", 0, "re-run the queries at this interval, reporting changes since the previous run (example: -watch 6h)"),
+		flagSet.StringSliceVarP(&options.Webhooks, "webhook", "wh", nil, "webhook notified of added and changed results, slack/dingtalk/feishu/wecom urls are detected, others receive json (example: -webhook slack=https://hooks.slack.com/services/x)", goflags.FileCommaSeparatedStringSliceOptions),
+		flagSet.StringVarP(&options.NotifyTemplate, "notify-template", "nt", "", "text/template file or inline template of notification messages"),
+		flagSet.IntVarP(&options.NotifyBatch, "notify-batch", "nb", 50, "maximum number of results per notification message"),
+	)
+
 	flagSet.CreateGroup("debug", "Debug",
 		flagSet.BoolVar(&options.Silent, "silent", false, "show only results in output"),
 		flagSet.CallbackVar(versionCallback, "version", "show version of the project"),
@@ -213,16 +338,8 @@ func ParseOptions() *Options {
 		options.Engine = append(options.Engine, "fofa")
 	}
 
-	// we make the assumption that input queries aren't that much
-	if fileutil.HasStdin() {
-		stdchan, err := fileutil.ReadFileWithReader(os.Stdin)
-		if err != nil {
-			gologger.Fatal().Msgf("couldn't read stdin: %s\n", err)
-		}
-		for query := range stdchan {
-			options.Query = append(options.Query, query)
-		}
-	}
+	// stdin and query files are streamed lazily by the runner
+	options.Stdin = fileutil.HasStdin()
 
 	// Validate the options passed by the user and if any
 	// invalid options have been used, exit.
@@ -258,8 +375,12 @@ func (Options *Options) loadConfigFrom(location string) error {
 func (options *Options) validateOptions() error {
 	// Check if domain, list of domains, or stdin info was provided.
 	// If none was provided, then return.
-	if genericutil.EqualsAll(0,
+	if !options.Stdin && genericutil.EqualsAll(0,
+		len(options.InputResults),
 		len(options.Query),
+		len(options.Target),
+		len(options.Favicon),
+		len(options.Cert),
 		len(options.Shodan),
 		len(options.Censys),
 		len(options.Quake),
@@ -332,6 +453,108 @@ func (options *Options) validateOptions() error {
 		return errors.New("no engine specified")
 	}
 
+	if options.Watch > 0 && len(options.InputResults) > 0 {
+		return errors.New("watch mode can't be used with input results")
+	}
+	if options.Watch > 0 && options.Stdin {
+		return errors.New("watch mode can't read queries from stdin")
+	}
+	if len(options.Webhooks) > 0 && options.Watch <= 0 && options.Diff == "" && options.Snapshot == "" {
+		return errors.New("webhooks require -watch, -diff or -snapshot")
+	}
+
+	if options.Raw && (options.Diff != "" || options.Snapshot != "" || options.Watch > 0) {
+		return errors.New("diff can't be used with raw output")
+	}
+
+	for _, facet := range options.Facets {
+		if !sources.IsFacetField(facet) {
+			return errorutil.New("invalid facet %s, supported facets are %s", facet, strings.Join(sources.FacetFields, ","))
+		}
+	}
+	if len(options.Facets) > 0 && (options.Watch > 0 || options.Diff != "" || options.Snapshot != "" || options.Raw) {
+		return errors.New("facets can't be used with watch, diff or raw output")
+	}
+	var err error
+	if options.since, err = ParseTime(options.Since); err != nil {
+		return err
+	}
+	if options.until, err = ParseTime(options.Until); err != nil {
+		return err
+	}
+	if !options.since.IsZero() && !options.until.IsZero() && options.since.After(options.until) {
+		return errors.New("since must be before until")
+	}
+
+	if options.engineOptions, err = sources.ParseEngineOptions(options.EngineOptions); err != nil {
+		return err
+	}
+
+	if options.ProxyList != "" {
+		if options.Proxy != "" {
+			return errors.New("proxy and proxy-list can't be used together")
+		}
+		if options.proxies, err = sources.ReadProxyList(options.ProxyList); err != nil {
+			return err
+		}
+	}
+	if options.ProxyRotation != sources.RotateRequest && options.ProxyRotation != sources.RotateAgent {
+		return errorutil.New("invalid proxy rotation %s, supported rotations are request,agent", options.ProxyRotation)
+	}
+	if options.proxyRoutes, err = sources.ParseProxyRoutes(options.ProxyRoutes); err != nil {
+		return err
+	}
+	if checkURL, err := url.Parse(options.ProxyCheckURL); err != nil || (checkURL.Scheme != "http" && checkURL.Scheme != "https") || checkURL.Host == "" {
+		return errorutil.New("invalid proxy check url %s", options.ProxyCheckURL)
+	}
+	if len(options.proxyRoutes) > 0 && options.ProxyList == "" {
+		return errors.New("proxy-route requires -proxy-list")
+	}
+
+	if options.cookieFiles, err = sources.ParseCookieFiles(options.CookieFiles); err != nil {
+		return err
+	}
+	for agent, file := range options.cookieFiles {
+		if _, ok := sources.DefaultCookieAuth[agent]; !ok {
+			return errorutil.New("%s agent doesn't support cookies, supported agents are %s", agent, strings.Join(sources.CookieAgents(), ","))
+		}
+		if !fileutil.FileExists(file) {
+			return errorutil.New("cookie file %s does not exist", file)
+		}
+	}
+
+	if options.Count || options.DryRun {
+		switch {
+		case options.Count && options.DryRun:
+			return errors.New("count and dry-run can't be used together")
+		case len(options.Facets) > 0:
+			return errors.New("count and dry-run can't be used with facets")
+		case len(options.InputResults) > 0:
+			return errors.New("count and dry-run can't be used with input results")
+		case options.Watch > 0 || options.Diff != "" || options.Snapshot != "" || options.Raw:
+			return errors.New("count and dry-run can't be used with watch, diff or raw output")
+		}
+	}
+
+	if _, err := ParseExports(options.Exports); err != nil {
+		return err
+	}
+
+	switch options.Report {
+	case "", ReportFormatXLSX, ReportFormatHTML, ReportFormatMarkdown:
+	default:
+		return errorutil.New("invalid report format %s, supported formats are xlsx,html,md", options.Report)
+	}
+	if options.ReportFile != "" && options.Report == "" {
+		return errors.New("-report-output requires -report")
+	}
+
+	switch options.GraphFormat {
+	case "", GraphFormatDOT, GraphFormatGraphML, GraphFormatJSON:
+	default:
+		return errorutil.New("invalid graph format %s, supported formats are dot,graphml,json", options.GraphFormat)
+	}
+
 	return nil
 }
 
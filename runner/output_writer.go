@@ -1,34 +1,42 @@
 package runner
 
 import (
-	"crypto/sha1"
-	"fmt"
 	"io"
 	"os"
 	"sync"
 
-	lru "github.com/hashicorp/golang-lru"
+	"github.com/projectdiscovery/gologger"
 	"github.com/wjlin0/uncover/sources"
 )
 
 type OutputWriter struct {
-	cache   *lru.Cache
+	dedupe  *Dedupe
 	writers []io.Writer
+	sinks   []Sink
 	sync.RWMutex
 }
 
-func NewOutputWriter() (*OutputWriter, error) {
-	lastPrintedCache, err := lru.New(2048)
-	if err != nil {
-		return nil, err
+// NewOutputWriter creates an output writer dropping duplicates with dedupe,
+// a default in-memory dedupe is used when dedupe is nil
+func NewOutputWriter(dedupe *Dedupe) (*OutputWriter, error) {
+	if dedupe == nil {
+		var err error
+		if dedupe, err = NewDedupe(nil); err != nil {
+			return nil, err
+		}
 	}
-	return &OutputWriter{cache: lastPrintedCache}, nil
+	return &OutputWriter{dedupe: dedupe}, nil
 }
 
 func (o *OutputWriter) AddWriters(writers ...io.Writer) {
 	o.writers = append(o.writers, writers...)
 }
 
+// AddSinks adds sinks receiving the results which are not duplicates
+func (o *OutputWriter) AddSinks(sinks ...Sink) {
+	o.sinks = append(o.sinks, sinks...)
+}
+
 // Write writes the data taken as input using only
 // the writer(s) with that name.
 func (o *OutputWriter) Write(data []byte) {
@@ -43,36 +51,62 @@ func (o *OutputWriter) Write(data []byte) {
 
 func (o *OutputWriter) findDuplicate(data string) bool {
 	// check if we've already printed this data
-	itemHash := sha1.Sum([]byte(data))
-	if o.cache.Contains(itemHash) {
-		return true
-	}
-	o.cache.Add(itemHash, struct{}{})
-	return false
+	return o.dedupe.IsDuplicate(data)
+}
+
+// Dropped returns the number of duplicate results which were not written
+func (o *OutputWriter) Dropped() int64 {
+	return o.dedupe.Dropped()
 }
 
 // WriteString writes the string taken as input using only
-func (o *OutputWriter) WriteString(data string) {
+// and reports whether it was written or dropped as duplicate
+func (o *OutputWriter) WriteString(data string) bool {
 	if o.findDuplicate(data) {
-		return
+		return false
 	}
 	o.Write([]byte(data))
+	return true
+}
+
+// WriteStringResult writes data formatted from result and sends result to
+// the sinks, it reports whether data was written or dropped as duplicate
+func (o *OutputWriter) WriteStringResult(data string, result sources.Result) bool {
+	if !o.WriteString(data) {
+		return false
+	}
+	o.send(result)
+	return true
 }
 
 // WriteJsonData writes the result taken as input in JSON format
 func (o *OutputWriter) WriteJsonData(data sources.Result) {
-	if o.findDuplicate(fmt.Sprintf("%s:%d", data.IP, data.Port)) {
+	if o.findDuplicate(o.dedupe.Key(data)) {
 		return
 	}
 	o.Write([]byte(data.JSON()))
+	o.send(data)
 }
 
 func (o *OutputWriter) WriteCSVData(data sources.Result) {
-	if o.findDuplicate(fmt.Sprintf("%s:%d", data.IP, data.Port)) {
+	if o.findDuplicate(o.dedupe.Key(data)) {
 		return
 	}
 	o.Write([]byte(data.CSV()))
+	o.send(data)
+}
+
+func (o *OutputWriter) send(result sources.Result) {
+	for _, sink := range o.sinks {
+		if err := sink.Send(result); err != nil {
+			gologger.Error().Msgf("Could not send result to sink: %s\n", err)
+		}
+	}
+}
 
+// Reset forgets the results written so far, they are written again when seen
+func (o *OutputWriter) Reset() error {
+	return o.dedupe.Reset()
 }
 
 // Close closes the output writers
@@ -83,4 +117,10 @@ func (o *OutputWriter) Close() {
 			fileWriter.Close()
 		}
 	}
+	for _, sink := range o.sinks {
+		if err := sink.Close(); err != nil {
+			gologger.Error().Msgf("Could not close sink: %s\n", err)
+		}
+	}
+	_ = o.dedupe.Close()
 }
@@ -0,0 +1,270 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	"github.com/wjlin0/uncover/sources"
+	util "github.com/wjlin0/uncover/utils"
+)
+
+const (
+	ReportFormatXLSX     = "xlsx"
+	ReportFormatHTML     = "html"
+	ReportFormatMarkdown = "md"
+)
+
+// DefaultReportName is the name of the report file without extension
+var DefaultReportName = "uncover-report"
+
+// noQuery names the section of the results returned without a query
+const noQuery = "(no query)"
+
+// Report collects the results of a run grouped by query for the xlsx, html and markdown reports
+type Report struct {
+	Started  time.Time
+	sections map[string][]sources.Result
+	seen     map[string]struct{}
+	sync.Mutex
+}
+
+// ReportCount is the number of results of an engine, a domain, a port or a query
+type ReportCount struct {
+	Name    string
+	Results int
+	// Engines are the per engine counts of a query (example: fofa:10 shodan:2)
+	Engines string
+}
+
+// ReportSection is the table of the results of a query, sorted by engine, domain then port
+type ReportSection struct {
+	Query   string
+	Engines string
+	Rows    [][]string
+}
+
+// ReportData is the content of a report
+type ReportData struct {
+	Started   time.Time
+	Generated time.Time
+	Results   int
+	Engines   []ReportCount
+	Domains   []ReportCount
+	Ports     []ReportCount
+	Queries   []ReportCount
+	Headers   []string
+	Sections  []ReportSection
+}
+
+// NewReport creates an empty report of a run started now
+func NewReport() *Report {
+	return &Report{
+		Started:  time.Now(),
+		sections: make(map[string][]sources.Result),
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// Add adds result to the section of its query, duplicates of a query are dropped
+func (r *Report) Add(result sources.Result) {
+	if result.Error != nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+
+	query := result.Query
+	if query == "" {
+		query = noQuery
+	}
+	key := strings.Join([]string{query, result.Source, result.IP, strconv.Itoa(result.Port), strings.ToLower(result.Host), result.Url}, "\x00")
+	if _, ok := r.seen[key]; ok {
+		return
+	}
+	r.seen[key] = struct{}{}
+	r.sections[query] = append(r.sections[query], result)
+}
+
+// Data returns the content of the report
+func (r *Report) Data() *ReportData {
+	r.Lock()
+	defer r.Unlock()
+
+	data := &ReportData{
+		Started:   r.Started,
+		Generated: time.Now(),
+		Headers:   (&sources.Result{}).CSVHeaders(),
+	}
+	engines, domains, ports := map[string]int{}, map[string]int{}, map[string]int{}
+	queries := make([]string, 0, len(r.sections))
+	for query := range r.sections {
+		queries = append(queries, query)
+	}
+	sort.Strings(queries)
+
+	for _, query := range queries {
+		results := r.sections[query]
+		sort.SliceStable(results, func(i, j int) bool {
+			a, b := results[i], results[j]
+			if a.Source != b.Source {
+				return a.Source < b.Source
+			}
+			if domainA, domainB := reportDomain(a.Host), reportDomain(b.Host); domainA != domainB {
+				return domainA < domainB
+			}
+			if a.Host != b.Host {
+				return a.Host < b.Host
+			}
+			if a.Port != b.Port {
+				return a.Port < b.Port
+			}
+			return a.IP < b.IP
+		})
+		queryEngines := map[string]int{}
+		section := ReportSection{Query: query}
+		for _, result := range results {
+			queryEngines[result.Source]++
+			engines[result.Source]++
+			if domain := reportDomain(result.Host); domain != "" {
+				domains[domain]++
+			}
+			if result.Port > 0 {
+				ports[strconv.Itoa(result.Port)]++
+			}
+			section.Rows = append(section.Rows, reportRow(data.Headers, result))
+		}
+		section.Engines = joinCounts(queryEngines)
+		data.Sections = append(data.Sections, section)
+		data.Queries = append(data.Queries, ReportCount{Name: query, Results: len(results), Engines: section.Engines})
+		data.Results += len(results)
+	}
+	data.Engines = sortedCounts(engines)
+	data.Domains = sortedCounts(domains)
+	data.Ports = sortedCounts(ports)
+	return data
+}
+
+// Write writes the report in format to writer
+func (r *Report) Write(writer io.Writer, format string) error {
+	data := r.Data()
+	switch format {
+	case ReportFormatXLSX:
+		return writeXLSX(data, writer)
+	case ReportFormatHTML:
+		return writeHTMLReport(data, writer)
+	case ReportFormatMarkdown:
+		return writeMarkdownReport(data, writer)
+	default:
+		return errorutil.New("invalid report format %s, supported formats are xlsx,html,md", format)
+	}
+}
+
+// WriteFile writes the report in format to file
+func (r *Report) WriteFile(file, format string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not create report file %s", file)
+	}
+	defer f.Close()
+	return r.Write(f, format)
+}
+
+// reportRow returns the csv fields of result, the timestamp being formatted as a date
+func reportRow(headers []string, result sources.Result) []string {
+	row := result.CSVValues()
+	for i, header := range headers {
+		if header == "timestamp" && result.Timestamp > 0 {
+			row[i] = time.Unix(result.Timestamp, 0).Format("2006-01-02 15:04:05")
+		}
+	}
+	return row
+}
+
+// reportDomain returns the registered domain of host, empty for ips
+func reportDomain(host string) string {
+	host = strings.ToLower(host)
+	if host == "" || !util.IsValidDomain(host) {
+		return ""
+	}
+	return util.GetMainDomain(host)
+}
+
+// sortedCounts returns counts ordered by decreasing number of results then name
+func sortedCounts(counts map[string]int) []ReportCount {
+	sorted := make([]ReportCount, 0, len(counts))
+	for name, results := range counts {
+		sorted = append(sorted, ReportCount{Name: name, Results: results})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Results != sorted[j].Results {
+			return sorted[i].Results > sorted[j].Results
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func joinCounts(counts map[string]int) string {
+	var parts []string
+	for _, count := range sortedCounts(counts) {
+		parts = append(parts, fmt.Sprintf("%s:%d", count.Name, count.Results))
+	}
+	return strings.Join(parts, " ")
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r", "", "\n", " ")
+
+func writeMarkdownReport(data *ReportData, writer io.Writer) error {
+	var builder strings.Builder
+	table := func(headers []string, rows [][]string) {
+		builder.WriteString("| " + strings.Join(headers, " | ") + " |\n")
+		builder.WriteString(strings.Repeat("| --- ", len(headers)) + "|\n")
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = markdownEscaper.Replace(cell)
+			}
+			builder.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		builder.WriteString("\n")
+	}
+	counts := func(title, name string, counts []ReportCount) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Fprintf(&builder, "## %s\n\n", title)
+		rows := make([][]string, len(counts))
+		for i, count := range counts {
+			rows[i] = []string{count.Name, strconv.Itoa(count.Results)}
+		}
+		table([]string{name, "results"}, rows)
+	}
+
+	builder.WriteString("# Uncover Report\n\n")
+	fmt.Fprintf(&builder, "- Run: %s\n- Generated: %s\n- Results: %d\n\n", formatTime(data.Started), formatTime(data.Generated), data.Results)
+	if len(data.Queries) > 0 {
+		builder.WriteString("## Queries\n\n")
+		rows := make([][]string, len(data.Queries))
+		for i, query := range data.Queries {
+			rows[i] = []string{query.Name, strconv.Itoa(query.Results), query.Engines}
+		}
+		table([]string{"query", "results", "engines"}, rows)
+	}
+	counts("Engines", "engine", data.Engines)
+	counts("Domains", "domain", data.Domains)
+	counts("Ports", "port", data.Ports)
+	for _, section := range data.Sections {
+		fmt.Fprintf(&builder, "## Query: %s\n\n", markdownEscaper.Replace(section.Query))
+		fmt.Fprintf(&builder, "%d results (%s)\n\n", len(section.Rows), section.Engines)
+		table(data.Headers, section.Rows)
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
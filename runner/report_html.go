@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlReportTemplate is a single file report, clicking a column header sorts its table
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": formatTime,
+	"counts": func(title, name string, counts []ReportCount) htmlCounts {
+		return htmlCounts{Title: title, Name: name, Counts: counts}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Uncover Report {{time .Started}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; margin-bottom: 2em; font-size: 14px; }
+th, td { border: 1px solid #d0d7de; padding: 4px 10px; text-align: left; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; }
+th[data-order="asc"]::after { content: " \25B2"; }
+th[data-order="desc"]::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fafbfc; }
+code { background: #f6f8fa; padding: 2px 4px; }
+</style>
+</head>
+<body>
+<h1>Uncover Report</h1>
+<ul>
+<li>Run: {{time .Started}}</li>
+<li>Generated: {{time .Generated}}</li>
+<li>Results: {{.Results}}</li>
+</ul>
+{{- if .Queries}}
+<h2>Queries</h2>
+<table class="sortable">
+<thead><tr><th>query</th><th>results</th><th>engines</th></tr></thead>
+<tbody>
+{{- range $i, $query := .Queries}}
+<tr><td><a href="#query-{{$i}}"><code>{{$query.Name}}</code></a></td><td>{{$query.Results}}</td><td>{{$query.Engines}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- template "counts" (counts "Engines" "engine" .Engines)}}
+{{- template "counts" (counts "Domains" "domain" .Domains)}}
+{{- template "counts" (counts "Ports" "port" .Ports)}}
+{{- $headers := .Headers}}
+{{- range $i, $section := .Sections}}
+<h2 id="query-{{$i}}">Query: <code>{{$section.Query}}</code></h2>
+<p>{{len $section.Rows}} results ({{$section.Engines}})</p>
+<table class="sortable">
+<thead><tr>{{range $headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range $section.Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0], index = th.cellIndex;
+    var order = th.dataset.order === "asc" ? "desc" : "asc";
+    table.querySelectorAll("th").forEach(function (other) { delete other.dataset.order; });
+    th.dataset.order = order;
+    var rows = Array.from(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[index].textContent, y = b.cells[index].textContent;
+      var result = (x !== "" && y !== "" && !isNaN(x) && !isNaN(y)) ? x - y : x.localeCompare(y, undefined, {numeric: true});
+      return order === "asc" ? result : -result;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+{{define "counts"}}
+{{- if .Counts}}
+<h2>{{.Title}}</h2>
+<table class="sortable">
+<thead><tr><th>{{.Name}}</th><th>results</th></tr></thead>
+<tbody>
+{{- range .Counts}}
+<tr><td>{{.Name}}</td><td>{{.Results}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- end}}
+`))
+
+type htmlCounts struct {
+	Title  string
+	Name   string
+	Counts []ReportCount
+}
+
+func writeHTMLReport(data *ReportData, writer io.Writer) error {
+	return htmlReportTemplate.Execute(writer, data)
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestReport(t *testing.T) {
+	report := NewReport()
+	report.Add(sources.Result{Source: "shodan", Query: "title:admin", IP: "10.0.0.1", Port: 443, Host: "www.example.com"})
+	report.Add(sources.Result{Source: "fofa", Query: "title:admin", IP: "10.0.0.2", Port: 80, Host: "api.example.com"})
+	report.Add(sources.Result{Source: "fofa", Query: "title:admin", IP: "10.0.0.2", Port: 80, Host: "api.example.com"})
+	report.Add(sources.Result{Source: "fofa", Query: "app=\"a|b\"", IP: "10.0.0.3", Port: 8080, Host: "10.0.0.3"})
+
+	data := report.Data()
+	require.Equal(t, 3, data.Results, "duplicates of a query are dropped")
+	require.Equal(t, []ReportCount{{Name: "fofa", Results: 2}, {Name: "shodan", Results: 1}}, data.Engines)
+	require.Equal(t, []ReportCount{{Name: "example.com", Results: 2}}, data.Domains)
+	require.Len(t, data.Sections, 2)
+	require.Equal(t, "title:admin", data.Sections[1].Query)
+	require.Equal(t, "fofa:1 shodan:1", data.Sections[1].Engines)
+	require.Equal(t, "fofa", data.Sections[1].Rows[0][1], "rows are sorted by engine")
+
+	var buffer bytes.Buffer
+	require.Nil(t, report.Write(&buffer, ReportFormatMarkdown))
+	require.Contains(t, buffer.String(), "## Query: app=\"a\\|b\"")
+	require.Contains(t, buffer.String(), "| timestamp | source | IP | port | host | url |")
+
+	buffer.Reset()
+	require.Nil(t, report.Write(&buffer, ReportFormatHTML))
+	require.Contains(t, buffer.String(), "<code>app=&#34;a|b&#34;</code>")
+	require.Contains(t, buffer.String(), `<table class="sortable">`)
+
+	buffer.Reset()
+	require.Nil(t, report.Write(&buffer, ReportFormatXLSX))
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.Nil(t, err)
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		f, err := file.Open()
+		require.Nil(t, err)
+		content, _ := io.ReadAll(f)
+		files[file.Name] = string(content)
+	}
+	require.Contains(t, files, "[Content_Types].xml")
+	require.Contains(t, files["xl/workbook.xml"], `<sheet name="Summary" sheetId="1" r:id="rId1"/>`)
+	require.Contains(t, files["xl/workbook.xml"], `<sheet name="title_admin" sheetId="3" r:id="rId3"/>`)
+	require.True(t, strings.Contains(files["xl/worksheets/sheet3.xml"], `<v>443</v>`), "ports are number cells")
+
+	require.NotNil(t, report.Write(&buffer, "pdf"))
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	names := map[string]bool{"summary": true}
+	require.Equal(t, "query 1", xlsxSheetName("", 1, names))
+	require.Equal(t, "summary (2)", xlsxSheetName("summary", 2, names))
+	long := strings.Repeat("a", 40)
+	require.Equal(t, strings.Repeat("a", 31), xlsxSheetName(long, 3, names))
+	require.Equal(t, strings.Repeat("a", 27)+" (2)", xlsxSheetName(long, 4, names))
+	require.Equal(t, "AA", xlsxColumn(26))
+}
@@ -0,0 +1,206 @@
+package runner
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxMainNamespace = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxPackageRels   = "http://schemas.openxmlformats.org/package/2006/relationships"
+	// xlsxMaxSheetName is the maximum length of a sheet name in excel
+	xlsxMaxSheetName = 31
+)
+
+// xlsxStyles has the default cell style and a bold one (s="1") for headers
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="` + xlsxMainNamespace + `"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`
+
+// xlsxSheet is a worksheet of the workbook, bold rows are headers
+type xlsxSheet struct {
+	name string
+	rows [][]string
+	bold map[int]bool
+	// filter adds an auto filter on the table starting at the first row
+	filter bool
+}
+
+func (s *xlsxSheet) add(bold bool, cells ...string) {
+	if bold {
+		s.bold[len(s.rows)] = true
+	}
+	s.rows = append(s.rows, cells)
+}
+
+// writeXLSX writes a workbook with a summary sheet and one sheet of results per query
+func writeXLSX(data *ReportData, writer io.Writer) error {
+	summary := &xlsxSheet{name: "Summary", bold: map[int]bool{}}
+	summary.add(true, "Uncover Report")
+	summary.add(false, "Run", formatTime(data.Started))
+	summary.add(false, "Generated", formatTime(data.Generated))
+	summary.add(false, "Results", strconv.Itoa(data.Results))
+	if len(data.Queries) > 0 {
+		summary.add(false)
+		summary.add(true, "query", "results", "engines", "sheet")
+	}
+	sheets := []*xlsxSheet{summary}
+	names := map[string]bool{strings.ToLower(summary.name): true}
+	for i, section := range data.Sections {
+		sheet := &xlsxSheet{name: xlsxSheetName(section.Query, i+1, names), bold: map[int]bool{0: true}, filter: true}
+		sheet.rows = append([][]string{data.Headers}, section.Rows...)
+		sheets = append(sheets, sheet)
+		query := data.Queries[i]
+		summary.add(false, query.Name, strconv.Itoa(query.Results), query.Engines, sheet.name)
+	}
+	for _, counts := range []struct {
+		name   string
+		counts []ReportCount
+	}{{"engine", data.Engines}, {"domain", data.Domains}, {"port", data.Ports}} {
+		if len(counts.counts) == 0 {
+			continue
+		}
+		summary.add(false)
+		summary.add(true, counts.name, "results")
+		for _, count := range counts.counts {
+			summary.add(false, count.Name, strconv.Itoa(count.Results))
+		}
+	}
+
+	zipWriter := zip.NewWriter(writer)
+	write := func(name, content string) error {
+		file, err := zipWriter.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(file, content)
+		return err
+	}
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="` + xlsxMainNamespace + `" xmlns:r="` + xlsxRelationships + `"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="` + xlsxPackageRels + `">`)
+	for i, sheet := range sheets {
+		id := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, id)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.name), id, id)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, id, xlsxRelationships, id)
+		if err := write(fmt.Sprintf("xl/worksheets/sheet%d.xml", id), sheet.xml()); err != nil {
+			return err
+		}
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/></Relationships>`, len(sheets)+1, xlsxRelationships)
+
+	for _, file := range []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="` + xlsxPackageRels + `"><Relationship Id="rId1" Type="` + xlsxRelationships + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		if err := write(file.name, file.content); err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
+
+// xml returns the worksheet xml, the cells are inline strings except integers
+func (s *xlsxSheet) xml() string {
+	var builder strings.Builder
+	builder.WriteString(xml.Header + `<worksheet xmlns="` + xlsxMainNamespace + `"><sheetData>`)
+	columns := 0
+	for i, row := range s.rows {
+		fmt.Fprintf(&builder, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			if cell == "" {
+				continue
+			}
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			style := ""
+			if s.bold[i] {
+				style = ` s="1"`
+			}
+			if isXLSXNumber(cell) {
+				fmt.Fprintf(&builder, `<c r="%s"%s><v>%s</v></c>`, ref, style, cell)
+			} else {
+				fmt.Fprintf(&builder, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(cell))
+			}
+		}
+		builder.WriteString(`</row>`)
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	builder.WriteString(`</sheetData>`)
+	if s.filter && columns > 0 {
+		fmt.Fprintf(&builder, `<autoFilter ref="A1:%s%d"/>`, xlsxColumn(columns-1), len(s.rows))
+	}
+	builder.WriteString(`</worksheet>`)
+	return builder.String()
+}
+
+// xlsxSheetName returns a unique sheet name for query, stripped of the characters excel refuses
+func xlsxSheetName(query string, index int, names map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(query))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = fmt.Sprintf("query %d", index)
+	}
+	if runes := []rune(name); len(runes) > xlsxMaxSheetName {
+		name = string(runes[:xlsxMaxSheetName])
+	}
+	for candidate, n := name, 2; ; n++ {
+		if !names[strings.ToLower(candidate)] {
+			names[strings.ToLower(candidate)] = true
+			return candidate
+		}
+		suffix := fmt.Sprintf(" (%d)", n)
+		runes := []rune(name)
+		if len(runes)+len(suffix) > xlsxMaxSheetName {
+			runes = runes[:xlsxMaxSheetName-len(suffix)]
+		}
+		candidate = string(runes) + suffix
+	}
+}
+
+// xlsxColumn returns the column letters of the zero based index (A, B, ..., AA)
+func xlsxColumn(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// isXLSXNumber reports whether cell is an integer written as a number cell, integers
+// with leading zeros stay strings
+func isXLSXNumber(cell string) bool {
+	if len(cell) > 15 || (len(cell) > 1 && cell[0] == '0') {
+		return false
+	}
+	_, err := strconv.Atoi(cell)
+	return err == nil
+}
+
+func xmlEscape(value string) string {
+	var builder strings.Builder
+	_ = xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
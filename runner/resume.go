@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// resumeState returns the directory of the state of the runs writing to outputFile,
+// each output file has its own so that runs writing elsewhere don't share it
+func resumeState(outputFile string) string {
+	name := "stdout"
+	if outputFile != "" {
+		if path, err := filepath.Abs(outputFile); err == nil {
+			outputFile = path
+		}
+		sum := sha1.Sum([]byte(outputFile))
+		name = hex.EncodeToString(sum[:])
+	}
+	return filepath.Join(DefaultResumeLocation, name)
+}
+
+// lockState locks the state directory for the run, the lock is released by closing
+// the returned file. an error is returned when another run holds it
+func lockState(state string) (*os.File, error) {
+	if err := fileutil.CreateFolders(state); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not create run state %s", state)
+	}
+	file, err := os.OpenFile(filepath.Join(state, "lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not lock run state %s", state)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, errorutil.New("the run state %s is used by another run of the same output", state)
+	}
+	return file, nil
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/projectdiscovery/gologger"
 	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
 	stringsutil "github.com/projectdiscovery/utils/strings"
 	"github.com/wjlin0/uncover"
 	"github.com/wjlin0/uncover/sources"
+	"github.com/wjlin0/uncover/utils"
 )
 
 // Runner is an instance of the uncover enumeration
@@ -19,6 +23,23 @@ type Runner struct {
 	options      *Options
 	service      *uncover.Service
 	outputWriter *OutputWriter
+	graph        *Graph
+	exports      []Export
+	assets       *AssetIndex
+	report       *Report
+	differ       *Differ
+	notifier     *Notifier
+	store        *Store
+	filter       *ResultFilter
+	// blocked are the block errors of the agents in the current run
+	blocked []*sources.BlockedError
+	// state is the directory of the de-duplication and query progress kept by -resume,
+	// it is locked by the run and removed once the run finished
+	state     string
+	stateLock *os.File
+	dedupe    *Dedupe
+	progress  *uncover.Progress
+	finished  bool
 }
 
 // NewRunner creates a new runner struct instance by parsing
@@ -30,10 +51,23 @@ func NewRunner(options *Options) (*Runner, error) {
 
 	opts := uncover.Options{
 		Agents:                 options.Engine,
-		Queries:                options.Query,
+		Concurrency:            options.Concurrency,
+		Recursive:              options.Recursive,
+		Depth:                  options.Depth,
+		PivotBudget:            options.PivotBudget,
+		Scope:                  options.Scope,
 		Limit:                  options.Limit,
+		Since:                  options.since,
+		Until:                  options.until,
+		EngineOptions:          options.engineOptions,
+		DisableSharding:        options.DisableSharding,
 		Proxy:                  options.Proxy,
 		ProxyAuth:              options.ProxyAuth,
+		ProxyList:              options.proxies,
+		ProxyRotation:          options.ProxyRotation,
+		ProxyCheckURL:          options.ProxyCheckURL,
+		ProxyRoutes:            options.proxyRoutes,
+		CookieFiles:            options.cookieFiles,
 		ProviderConfigLocation: options.Location,
 	}
 	service, err := uncover.New(&opts)
@@ -41,8 +75,40 @@ func NewRunner(options *Options) (*Runner, error) {
 		return nil, err
 	}
 	runner.service = service
+	service.Options.Targets = append(service.Options.Targets, runner.faviconTargets()...)
+	certTargets, err := runner.certTargets()
+	if err != nil {
+		return nil, err
+	}
+	service.Options.TypedTargets = append(service.Options.TypedTargets, certTargets...)
+
+	if runner.filter, err = ParseResultFilter(options.Filters); err != nil {
+		return nil, err
+	}
 
-	runner.outputWriter, err = NewOutputWriter()
+	dedupeOptions := &DedupeOptions{
+		Fields:  options.DedupeFields,
+		Backend: options.DedupeBackend,
+	}
+	resumed := false
+	if options.Resume && len(options.Facets) == 0 && !options.Count && !options.DryRun {
+		// the state of the run is kept until it finishes so that it can be resumed when interrupted
+		runner.state = resumeState(options.OutputFile)
+		if runner.stateLock, err = lockState(runner.state); err != nil {
+			return nil, err
+		}
+		progressFile := filepath.Join(runner.state, "progress.json")
+		resumed = fileutil.FileExists(progressFile)
+		dedupeOptions.Path = filepath.Join(runner.state, "dedupe")
+		if runner.progress, err = uncover.LoadProgress(progressFile); err != nil {
+			return nil, err
+		}
+		service.Options.Progress = runner.progress
+	}
+	if runner.dedupe, err = NewDedupe(dedupeOptions); err != nil {
+		return nil, err
+	}
+	runner.outputWriter, err = NewOutputWriter(runner.dedupe)
 	if err != nil {
 		return nil, err
 	}
@@ -51,64 +117,307 @@ func NewRunner(options *Options) (*Runner, error) {
 	//	runner.outputWriter.AddWriters(os.Stdout)
 	//}
 	if runner.options.OutputFile != "" {
-		outputFile, err := os.Create(runner.options.OutputFile)
+		createFile := os.Create
+		if resumed {
+			createFile = utils.AppendCreate
+		}
+		outputFile, err := createFile(runner.options.OutputFile)
 		if err != nil {
 			return nil, errorutil.New("could not create output file %s: %s", options.OutputFile, err)
 		}
 		runner.outputWriter.AddWriters(outputFile)
 	}
+	if options.Sink != "" {
+		sink, err := NewHTTPSink(&HTTPSinkOptions{
+			URL:           options.Sink,
+			Format:        options.SinkFormat,
+			Headers:       options.SinkHeaders,
+			Auth:          options.SinkAuth,
+			Gzip:          options.SinkGzip,
+			BatchSize:     options.SinkBatch,
+			FlushInterval: options.SinkInterval,
+			Retries:       options.Retries,
+			Timeout:       time.Duration(options.Timeout) * time.Second,
+			Spool:         options.SinkSpool,
+		})
+		if err != nil {
+			return nil, err
+		}
+		runner.outputWriter.AddSinks(sink)
+	}
+	if options.GraphFile != "" {
+		runner.graph = NewGraph()
+	}
+	if runner.exports, err = ParseExports(options.Exports); err != nil {
+		return nil, err
+	}
+	if len(runner.exports) > 0 {
+		runner.assets = NewAssetIndex()
+	}
+	if options.Report != "" {
+		runner.report = NewReport()
+		if options.ReportFile == "" {
+			options.ReportFile = DefaultReportName + "." + options.Report
+		}
+	}
+	if options.Watch > 0 && options.Snapshot == "" {
+		// watch mode keeps its state between runs in a snapshot
+		options.Snapshot = DefaultWatchSnapshot
+	}
+	if options.Diff != "" || options.Snapshot != "" {
+		runner.differ, err = NewDiffer(&DiffOptions{
+			Previous: options.Diff,
+			Snapshot: options.Snapshot,
+			Fields:   options.DiffFields,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.DB != "" {
+		if runner.store, err = OpenStore(options.DB); err != nil {
+			return nil, err
+		}
+	}
+	if len(options.Webhooks) > 0 {
+		runner.notifier, err = NewNotifier(&NotifyOptions{
+			Webhooks:  options.Webhooks,
+			Template:  options.NotifyTemplate,
+			BatchSize: options.NotifyBatch,
+			Retries:   options.Retries,
+			Timeout:   time.Duration(options.Timeout) * time.Second,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 	return runner, nil
 }
 
-// Run RunEnumeration runs the subdomain enumeration flow on the targets specified
+// Run RunEnumeration runs the subdomain enumeration flow on the targets specified,
+// it runs again at the -watch interval until ctx is done
 func (r *Runner) Run(ctx context.Context) error {
+	if len(r.options.Facets) > 0 {
+		return r.runFacets(ctx)
+	}
+	if r.options.Count {
+		return r.runCount(ctx)
+	}
+	if r.options.DryRun {
+		return r.runDryRun(ctx)
+	}
+	if r.options.Watch <= 0 {
+		return r.run(ctx)
+	}
+	for {
+		if err := r.run(ctx); err != nil {
+			gologger.Error().Msgf("Could not run enumeration: %s\n", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		// the next run is compared with the snapshot saved by this one
+		r.differ.Close()
+		differ, err := NewDiffer(&DiffOptions{Snapshot: r.options.Snapshot, Fields: r.options.DiffFields})
+		if err != nil {
+			return err
+		}
+		r.differ = differ
+		gologger.Info().Msgf("Next run in %s\n", r.options.Watch)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.options.Watch):
+		}
+		// changes of the next run are written even when their asset was written before
+		if err := r.outputWriter.Reset(); err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not reset de-duplication")
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context) error {
+	r.blocked, r.finished = nil, false
 	resultCallback := func(result sources.Result) {
 		if result.Source == "" {
 			result.Source = "unknown"
 		}
-		optionFields := r.options.OutputFields
-		switch {
-		case result.Error != nil:
-			gologger.Warning().Label(result.Source).Msgf("%s\n", result.Error.Error())
-		case r.options.JSON:
-			gologger.Verbose().Label(result.Source).Msgf("%s\n", result.JSON())
-			r.outputWriter.WriteJsonData(result)
-		case r.options.Raw:
-			gologger.Verbose().Label(result.Source).Msgf("%s\n", result.RawData())
-			r.outputWriter.WriteString(result.RawData())
-		default:
-			port := fmt.Sprint(result.Port)
-			replacer := strings.NewReplacer(
-				"ip", result.IP,
-				"host", result.Host,
-				"port", port,
-				"url", result.Url,
-			)
-			if (result.IP == "" || port == "0") && stringsutil.ContainsAny(r.options.OutputFields, "ip", "port") {
-				optionFields = "host"
+		if blocked, ok := sources.IsBlocked(result.Error); ok {
+			r.blocked = append(r.blocked, blocked)
+		}
+		if result.Error == nil && !r.filter.Match(result) {
+			return
+		}
+		if r.graph != nil {
+			r.graph.Add(result)
+		}
+		if r.assets != nil {
+			r.assets.Add(result)
+		}
+		if r.report != nil {
+			r.report.Add(result)
+		}
+		if r.store != nil {
+			if err := r.store.Record(result); err != nil {
+				gologger.Error().Msgf("Could not record result: %s\n", err)
 			}
-			outData := replacer.Replace(optionFields)
-			searchFor := []string{result.IP, port}
-			if result.Host != "" || r.options.OutputFile != "" {
-				searchFor = append(searchFor, result.Host)
+		}
+		if r.differ != nil && result.Error == nil {
+			change, changed := r.differ.Compare(result)
+			if !changed {
+				return
 			}
-			if stringsutil.ContainsAny(outData, searchFor...) {
-				if r.options.Verbose {
-					// if output is verbose include source name
-					gologger.Info().Label(result.Source).Msg(outData)
-				} else {
-					gologger.DefaultLogger.Print().Msg(outData)
-				}
-				r.outputWriter.WriteString(outData)
+			result.Change = change
+			// a first run only records the baseline to compare with
+			if r.notifier != nil && r.differ.HasPrevious() {
+				r.notifier.Add(result)
+			}
+		}
+		r.writeResult(result)
+	}
+	if r.store != nil {
+		run, err := r.store.BeginRun()
+		if err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not record run in %s", r.options.DB)
+		}
+		gologger.Verbose().Msgf("Recording run %s in %s\n", run, r.options.DB)
+	}
+	var err error
+	if len(r.options.InputResults) > 0 {
+		// offline mode, the saved results go through the same output pipeline
+		err = r.readInputResults(ctx, resultCallback)
+	} else {
+		// queries are read lazily from files and stdin while the service runs
+		r.service.Options.QueryStream = r.options.queryStream(ctx)
+		r.service.Options.TargetStream = r.options.targetStream(ctx)
+		err = r.service.ExecuteWithCallback(ctx, resultCallback)
+	}
+	if r.store != nil {
+		if err := r.store.FinishRun(); err != nil {
+			gologger.Error().Msgf("Could not record run: %s\n", err)
+		}
+	}
+	if r.notifier != nil {
+		r.notifier.Flush()
+	}
+	writeBlockedSummary(r.blocked)
+	if err != nil || ctx.Err() != nil {
+		if r.progress != nil {
+			gologger.Info().Msgf("Run interrupted, run it again with -resume to continue it\n")
+		}
+		return err
+	}
+	if r.differ != nil {
+		// removals are only known once every result of the run was seen
+		for _, result := range r.differ.Removed() {
+			r.writeResult(result)
+		}
+		if err := r.differ.Save(); err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not save snapshot %s", r.options.Snapshot)
+		}
+	}
+	// the next run of watch mode queries everything again
+	if err := r.progress.Clear(); err != nil {
+		gologger.Warning().Msgf("Could not clear run progress: %s\n", err)
+	}
+	r.finished = true
+	return nil
+}
+
+// writeResult writes result to the output in the configured format
+func (r *Runner) writeResult(result sources.Result) {
+	optionFields := r.options.OutputFields
+	switch {
+	case result.Error != nil:
+		gologger.Warning().Label(result.Source).Msgf("%s\n", result.Error.Error())
+	case r.options.JSON:
+		gologger.Verbose().Label(result.Source).Msgf("%s\n", result.JSON())
+		r.outputWriter.WriteJsonData(result)
+	case r.options.Raw:
+		gologger.Verbose().Label(result.Source).Msgf("%s\n", result.RawData())
+		r.outputWriter.WriteStringResult(result.RawData(), result)
+	default:
+		port := fmt.Sprint(result.Port)
+		replacer := strings.NewReplacer(
+			"ip", result.IP,
+			"host", result.Host,
+			"port", port,
+			"url", result.Url,
+		)
+		if (result.IP == "" || port == "0") && stringsutil.ContainsAny(r.options.OutputFields, "ip", "port") {
+			optionFields = "host"
+		}
+		outData := replacer.Replace(optionFields)
+		searchFor := []string{result.IP, port}
+		if result.Host != "" || r.options.OutputFile != "" {
+			searchFor = append(searchFor, result.Host)
+		}
+		if !stringsutil.ContainsAny(outData, searchFor...) {
+			return
+		}
+		if result.Change != "" {
+			outData = fmt.Sprintf("[%s] %s", result.Change, outData)
+		}
+		if r.outputWriter.WriteStringResult(outData, result) {
+			if r.options.Verbose {
+				// if output is verbose include source name
+				gologger.Info().Label(result.Source).Msg(outData)
+			} else {
+				gologger.DefaultLogger.Print().Msg(outData)
 			}
 		}
 	}
-	return r.service.ExecuteWithCallback(ctx, resultCallback)
 }
 
 // Close closes its resources
 func (r *Runner) Close() {
+	if r.graph != nil {
+		if err := r.graph.WriteFile(r.options.GraphFile, r.options.GraphFormat); err != nil {
+			gologger.Error().Msgf("Could not write graph: %s\n", err)
+		} else {
+			gologger.Info().Msgf("Wrote graph of %d nodes and %d edges to %s\n", len(r.graph.Nodes), len(r.graph.Edges), r.options.GraphFile)
+		}
+	}
+	for _, export := range r.exports {
+		if err := export.WriteFile(r.assets); err != nil {
+			gologger.Error().Msgf("Could not write %s export: %s\n", export.Format, err)
+		}
+	}
+	if r.report != nil {
+		if err := r.report.WriteFile(r.options.ReportFile, r.options.Report); err != nil {
+			gologger.Error().Msgf("Could not write report: %s\n", err)
+		} else {
+			gologger.Info().Msgf("Wrote %s report to %s\n", r.options.Report, r.options.ReportFile)
+		}
+	}
+	if r.differ != nil {
+		r.differ.Close()
+	}
+	if r.store != nil {
+		_ = r.store.Close()
+	}
+	if r.state != "" && !r.finished {
+		// the results written so far are dropped when the run is resumed
+		if err := r.dedupe.Save(); err != nil {
+			gologger.Error().Msgf("Could not save run de-duplication: %s\n", err)
+		}
+	}
 	if r.outputWriter != nil {
+		if dropped := r.outputWriter.Dropped(); dropped > 0 {
+			gologger.Info().Msgf("Dropped %d duplicate results\n", dropped)
+		}
 		r.outputWriter.Close()
 	}
+	if r.state == "" {
+		return
+	}
+	if !r.finished {
+		if err := r.progress.Save(); err != nil {
+			gologger.Error().Msgf("Could not save run progress: %s\n", err)
+		}
+	}
+	_ = r.stateLock.Close()
+	if r.finished {
+		_ = os.RemoveAll(r.state)
+	}
 }
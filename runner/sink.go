@@ -0,0 +1,400 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+)
+
+const (
+	SinkFormatNDJSON = "ndjson"
+	SinkFormatJSON   = "json"
+)
+
+var (
+	// DefaultSinkBatchSize is the number of results posted per request
+	DefaultSinkBatchSize = 100
+	// DefaultSinkFlushInterval is the maximum time a result waits before being posted
+	DefaultSinkFlushInterval = 10 * time.Second
+	// DefaultSinkQueueSize is the number of batches waiting to be posted, the batches
+	// over it are spooled
+	DefaultSinkQueueSize = 10
+)
+
+// Sink receives the results written by the output writer
+type Sink interface {
+	Send(result sources.Result) error
+	Close() error
+}
+
+// HTTPSinkOptions configures the http result sink
+type HTTPSinkOptions struct {
+	URL string
+	// Format is ndjson or json (a json array)
+	Format string
+	// Headers are sent with every request (example: X-Api-Key: key)
+	Headers []string
+	// Auth is either user:pass for basic auth or a full authorization header value (example: Bearer token)
+	Auth          string
+	Gzip          bool
+	BatchSize     int
+	FlushInterval time.Duration
+	// QueueSize is the number of batches waiting to be posted, DefaultSinkQueueSize when 0
+	QueueSize int
+	Retries   int
+	Timeout   time.Duration
+	// RetryWait is the minimum backoff before retrying a failed batch
+	RetryWait time.Duration
+	// Spool is the folder keeping the batches which could not be posted or queued,
+	// they are posted again once the queue is empty. without spool a full queue
+	// blocks Send
+	Spool string
+}
+
+// HTTPSink posts batches of results to an http endpoint from a background goroutine
+type HTTPSink struct {
+	options *HTTPSinkOptions
+	headers http.Header
+	client  *retryablehttp.Client
+	batch   [][]byte
+	queue   chan [][]byte
+	// spooling is set while batches are spooled instead of queued so that they are
+	// posted in order, until the spool is posted
+	spooling bool
+	sequence int
+	// failed are the results which could not be posted nor spooled
+	failed int
+	done   chan struct{}
+	flush  sync.WaitGroup
+	post   sync.WaitGroup
+	sync.Mutex
+}
+
+// NewHTTPSink creates an http sink from options
+func NewHTTPSink(options *HTTPSinkOptions) (*HTTPSink, error) {
+	if options.URL == "" {
+		return nil, errorutil.New("no sink url given")
+	}
+	switch options.Format {
+	case "":
+		options.Format = SinkFormatNDJSON
+	case SinkFormatNDJSON, SinkFormatJSON:
+	default:
+		return nil, errorutil.New("invalid sink format %s, supported formats are ndjson,json", options.Format)
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultSinkBatchSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = DefaultSinkFlushInterval
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultSinkQueueSize
+	}
+
+	s := &HTTPSink{options: options, headers: http.Header{}, queue: make(chan [][]byte, options.QueueSize), done: make(chan struct{})}
+	for _, header := range options.Headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, errorutil.New("invalid sink header %s, expected name: value", header)
+		}
+		s.headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	if auth := options.Auth; auth != "" {
+		if strings.Contains(auth, " ") {
+			s.headers.Set("Authorization", auth)
+		} else {
+			s.headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
+		}
+	}
+	if options.Spool != "" {
+		if err := fileutil.CreateFolders(options.Spool); err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("could not create sink spool %s", options.Spool)
+		}
+		// the batches spooled by a previous run are posted before the new ones
+		files, _ := spooled(options.Spool)
+		s.spooling = len(files) > 0
+	}
+
+	clientOptions := retryablehttp.DefaultOptionsSingle
+	clientOptions.RetryMax = options.Retries
+	if options.Timeout > 0 {
+		clientOptions.Timeout = options.Timeout
+	}
+	if options.RetryWait > 0 {
+		clientOptions.RetryWaitMin = options.RetryWait
+	}
+	clientOptions.CheckRetry = retryOnServerError
+	s.client = retryablehttp.NewClient(clientOptions)
+
+	s.flush.Add(1)
+	go s.flushEvery(options.FlushInterval)
+	s.post.Add(1)
+	go s.deliver()
+	return s, nil
+}
+
+// Send adds result to the batch, the batch is queued once full
+func (s *HTTPSink) Send(result sources.Result) error {
+	s.Lock()
+	defer s.Unlock()
+	s.batch = append(s.batch, []byte(result.JSON()))
+	if len(s.batch) < s.options.BatchSize {
+		return nil
+	}
+	return s.enqueue()
+}
+
+// Flush queues the batch
+func (s *HTTPSink) Flush() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.enqueue()
+}
+
+// enqueue queues the batch, it is spooled when the queue is full or while the spool
+// is posted. the caller holds the lock
+func (s *HTTPSink) enqueue() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	batch := s.batch
+	s.batch = nil
+	if s.options.Spool == "" {
+		s.queue <- batch
+		return nil
+	}
+	if !s.spooling {
+		select {
+		case s.queue <- batch:
+			return nil
+		default:
+			s.spooling = true
+		}
+	}
+	return s.spool(batch)
+}
+
+// Close queues the batch, waits for the queued batches to be posted and stops the sink
+func (s *HTTPSink) Close() error {
+	close(s.done)
+	s.flush.Wait()
+	err := s.Flush()
+	close(s.queue)
+	s.post.Wait()
+	if err != nil {
+		return err
+	}
+	if s.failed > 0 {
+		return errorutil.New("could not post %d results to %s", s.failed, s.options.URL)
+	}
+	return nil
+}
+
+func (s *HTTPSink) flushEvery(interval time.Duration) {
+	defer s.flush.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				gologger.Error().Msgf("Could not flush sink: %s\n", err)
+			}
+		}
+	}
+}
+
+// deliver posts the queued batches, then the spooled ones once the queue is empty.
+// the spool is posted again every flush interval while the endpoint is down
+func (s *HTTPSink) deliver() {
+	defer s.post.Done()
+	ticker := time.NewTicker(s.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case batch, ok := <-s.queue:
+			if !ok {
+				s.replaySpool()
+				return
+			}
+			if err := s.postBatch(batch); err != nil {
+				s.fail(batch, err)
+			}
+			if len(s.queue) == 0 {
+				s.replaySpool()
+			}
+		case <-ticker.C:
+			if len(s.queue) == 0 {
+				s.replaySpool()
+			}
+		}
+	}
+}
+
+// fail spools batch along with the queued batches so that they are posted after it,
+// batch is dropped without spool
+func (s *HTTPSink) fail(batch [][]byte, postErr error) {
+	if s.options.Spool == "" {
+		s.failed += len(batch)
+		gologger.Error().Msgf("Could not post %d results to %s: %s\n", len(batch), s.options.URL, postErr)
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.spooling = true
+	gologger.Warning().Msgf("Could not post %d results to %s, spooling them until it is back: %s\n", len(batch), s.options.URL, postErr)
+	for {
+		if err := s.spool(batch); err != nil {
+			s.failed += len(batch)
+			gologger.Error().Msgf("%s\n", err)
+		}
+		select {
+		case batch = <-s.queue:
+			if batch == nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *HTTPSink) postBatch(batch [][]byte) error {
+	var body bytes.Buffer
+	var writer io.Writer = &body
+	var zipper *gzip.Writer
+	if s.options.Gzip {
+		zipper = gzip.NewWriter(&body)
+		writer = zipper
+	}
+	contentType := "application/x-ndjson"
+	if s.options.Format == SinkFormatJSON {
+		contentType = "application/json"
+		_, _ = writer.Write([]byte("["))
+		_, _ = writer.Write(bytes.Join(batch, []byte(",")))
+		_, _ = writer.Write([]byte("]"))
+	} else {
+		for _, record := range batch {
+			_, _ = writer.Write(record)
+			_, _ = writer.Write([]byte("\n"))
+		}
+	}
+	if zipper != nil {
+		if err := zipper.Close(); err != nil {
+			return err
+		}
+	}
+
+	request, err := retryablehttp.NewRequest(http.MethodPost, s.options.URL, body.Bytes())
+	if err != nil {
+		return err
+	}
+	for name, values := range s.headers {
+		request.Header[name] = values
+	}
+	request.Header.Set("Content-Type", contentType)
+	if zipper != nil {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return errorutil.New("unexpected status code %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// spool keeps batch on disk to post it later. the caller holds the lock
+func (s *HTTPSink) spool(batch [][]byte) error {
+	s.sequence++
+	file := filepath.Join(s.options.Spool, fmt.Sprintf("batch-%d-%06d.ndjson", time.Now().UnixNano(), s.sequence))
+	data := append(bytes.Join(batch, []byte("\n")), '\n')
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not spool %d results", len(batch))
+	}
+	gologger.Verbose().Msgf("Spooled %d results to %s\n", len(batch), file)
+	return nil
+}
+
+// replaySpool posts the spooled batches oldest first, the batches are queued again
+// once the spool is empty
+func (s *HTTPSink) replaySpool() {
+	if s.options.Spool == "" {
+		return
+	}
+	for {
+		s.Lock()
+		files, err := spooled(s.options.Spool)
+		if err == nil && len(files) == 0 {
+			s.spooling = false
+		}
+		s.Unlock()
+		if err != nil || len(files) == 0 {
+			return
+		}
+		for _, file := range files {
+			batch, err := readSpool(file)
+			if err != nil {
+				gologger.Error().Msgf("Could not read spooled results %s: %s\n", file, err)
+				return
+			}
+			if err := s.postBatch(batch); err != nil {
+				gologger.Verbose().Msgf("Could not post spooled results to %s: %s\n", s.options.URL, err)
+				return
+			}
+			if err := os.Remove(file); err != nil {
+				gologger.Error().Msgf("Could not remove spooled results %s: %s\n", file, err)
+				return
+			}
+			gologger.Verbose().Msgf("Posted %d spooled results from %s\n", len(batch), file)
+		}
+	}
+}
+
+// spooled returns the batches of spool, oldest first
+func spooled(spool string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(spool, "batch-*.ndjson"))
+	sort.Strings(files)
+	return files, err
+}
+
+func readSpool(file string) ([][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var batch [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			batch = append(batch, append([]byte(nil), line...))
+		}
+	}
+	return batch, scanner.Err()
+}
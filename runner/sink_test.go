@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		batches [][]sources.Result
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "key", r.Header.Get("X-Api-Key"))
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user:pass", user+":"+pass)
+
+		reader, err := gzip.NewReader(r.Body)
+		require.Nil(t, err)
+		var batch []sources.Result
+		require.Nil(t, json.NewDecoder(reader).Decode(&batch))
+		mutex.Lock()
+		batches = append(batches, batch)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	sink, err := NewHTTPSink(&HTTPSinkOptions{
+		URL:       server.URL,
+		Format:    SinkFormatJSON,
+		Headers:   []string{"X-Api-Key: key"},
+		Auth:      "user:pass",
+		Gzip:      true,
+		BatchSize: 2,
+	})
+	require.Nil(t, err)
+	for port := 1; port <= 3; port++ {
+		require.Nil(t, sink.Send(sources.Result{IP: "1.1.1.1", Port: port}))
+	}
+	require.Nil(t, sink.Close())
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Len(t, batches, 2)
+	require.Len(t, batches[0], 2)
+	require.Equal(t, 3, batches[1][0].Port)
+}
+
+func TestHTTPSinkSpool(t *testing.T) {
+	var (
+		mutex sync.Mutex
+		down  = true
+		ports []int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		ports = append(ports, readPorts(t, r)...)
+	}))
+	defer server.Close()
+
+	spool := t.TempDir()
+	sink, err := NewHTTPSink(&HTTPSinkOptions{
+		URL:           server.URL,
+		BatchSize:     1,
+		FlushInterval: 10 * time.Millisecond,
+		Retries:       1,
+		RetryWait:     time.Millisecond,
+		Spool:         spool,
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, sink.Send(sources.Result{Port: 1}))
+	require.Nil(t, sink.Send(sources.Result{Port: 2}))
+	require.Eventually(t, func() bool {
+		spooled, _ := filepath.Glob(filepath.Join(spool, "*.ndjson"))
+		return len(spooled) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mutex.Lock()
+	down = false
+	mutex.Unlock()
+	require.Nil(t, sink.Send(sources.Result{Port: 3}))
+	require.Nil(t, sink.Close())
+
+	spooled, _ := filepath.Glob(filepath.Join(spool, "*.ndjson"))
+	require.Empty(t, spooled)
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, []int{1, 2, 3}, ports, "spooled batches are posted first, in order")
+}
+
+func TestHTTPSinkQueueFull(t *testing.T) {
+	var (
+		mutex sync.Mutex
+		ports []int
+	)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mutex.Lock()
+		defer mutex.Unlock()
+		ports = append(ports, readPorts(t, r)...)
+	}))
+	defer server.Close()
+
+	spool := t.TempDir()
+	sink, err := NewHTTPSink(&HTTPSinkOptions{URL: server.URL, BatchSize: 1, QueueSize: 1, Spool: spool})
+	require.Nil(t, err)
+
+	// the endpoint hangs, yet sending never waits for it
+	for port := 1; port <= 5; port++ {
+		require.Nil(t, sink.Send(sources.Result{Port: port}))
+	}
+	spooled, _ := filepath.Glob(filepath.Join(spool, "*.ndjson"))
+	require.NotEmpty(t, spooled)
+
+	close(release)
+	require.Nil(t, sink.Close())
+	spooled, _ = filepath.Glob(filepath.Join(spool, "*.ndjson"))
+	require.Empty(t, spooled)
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, []int{1, 2, 3, 4, 5}, ports, "spooled batches are posted after the queued ones")
+}
+
+func readPorts(t *testing.T, r *http.Request) []int {
+	var ports []int
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var result sources.Result
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &result))
+		ports = append(ports, result.Port)
+	}
+	return ports
+}
@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// stixNamespace is the namespace of the deterministic ids of STIX cyber observables
+var stixNamespace = uuid.MustParse("00abedb4-aa42-466c-9c01-fed23315a9b7")
+
+const stixTimestamp = "2006-01-02T15:04:05.000Z"
+
+type stixBundle struct {
+	Type    string        `json:"type"`
+	ID      string        `json:"id"`
+	Objects []interface{} `json:"objects"`
+}
+
+type stixObject map[string]interface{}
+
+// stixWriter builds the objects of a bundle, each object is added once
+type stixWriter struct {
+	objects []interface{}
+	seen    map[string]stixObject
+	created string
+}
+
+// writeSTIX writes a STIX 2.1 bundle of the ip, domain-name, url and network-traffic
+// observables of assets linked by relationships, x_uncover_source and x_uncover_query
+// keep the engines and queries which returned each observable
+func writeSTIX(assets *AssetIndex, writer io.Writer) error {
+	s := &stixWriter{seen: make(map[string]stixObject), created: time.Now().UTC().Format(stixTimestamp)}
+	for _, host := range assets.Hosts() {
+		var ipRef string
+		if host.IP != "" {
+			ipType := "ipv4-addr"
+			if ip := net.ParseIP(host.IP); ip != nil && ip.To4() == nil {
+				ipType = "ipv6-addr"
+			}
+			ipRef = s.observable(ipType, stixObject{"value": host.IP}, host.Sources, host.Queries, host.Pivots)
+		}
+		for _, name := range host.Hostnames {
+			domainRef := s.observable("domain-name", stixObject{"value": name}, host.Sources, host.Queries, host.Pivots)
+			if ipRef != "" {
+				s.relationship(domainRef, "resolves-to", ipRef)
+			}
+		}
+		if ipRef == "" {
+			continue
+		}
+		for _, port := range host.SortedPorts() {
+			protocols := []string{"tcp"}
+			switch webScheme(port) {
+			case "https":
+				protocols = append(protocols, "ssl", "http")
+			case "http":
+				protocols = append(protocols, "http")
+			}
+			trafficRef := s.observable("network-traffic", stixObject{
+				"dst_ref":   ipRef,
+				"dst_port":  port.Port,
+				"protocols": protocols,
+			}, port.Sources, port.Queries, nil)
+			for _, u := range port.URLs {
+				urlRef := s.observable("url", stixObject{"value": u}, port.Sources, port.Queries, nil)
+				s.relationship(urlRef, "related-to", trafficRef)
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stixBundle{
+		Type:    "bundle",
+		ID:      "bundle--" + uuid.NewString(),
+		Objects: s.objects,
+	})
+}
+
+// observable adds the cyber observable of kind with the id contributing properties
+// and returns its id, the provenance of an observable seen twice is merged
+func (s *stixWriter) observable(kind string, properties stixObject, sources, queries, pivots []string) string {
+	// maps are marshalled with sorted keys, as the canonical form of the id properties
+	canonical, _ := json.Marshal(properties)
+	id := kind + "--" + uuid.NewSHA1(stixNamespace, canonical).String()
+	object, ok := s.seen[id]
+	if !ok {
+		object = stixObject{"type": kind, "spec_version": "2.1", "id": id}
+		for key, value := range properties {
+			object[key] = value
+		}
+		s.seen[id] = object
+		s.objects = append(s.objects, object)
+	}
+	mergeProvenance(object, "x_uncover_source", sources)
+	mergeProvenance(object, "x_uncover_query", queries)
+	mergeProvenance(object, "x_uncover_pivot", pivots)
+	return id
+}
+
+// relationship adds the relationship from source to target, once
+func (s *stixWriter) relationship(source, kind, target string) {
+	key := source + kind + target
+	if _, ok := s.seen[key]; ok {
+		return
+	}
+	object := stixObject{
+		"type":              "relationship",
+		"spec_version":      "2.1",
+		"id":                "relationship--" + uuid.NewString(),
+		"created":           s.created,
+		"modified":          s.created,
+		"relationship_type": kind,
+		"source_ref":        source,
+		"target_ref":        target,
+	}
+	s.seen[key] = object
+	s.objects = append(s.objects, object)
+}
+
+func mergeProvenance(object stixObject, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	merged, _ := object[key].([]string)
+	for _, value := range values {
+		merged = appendUnique(merged, value)
+	}
+	object[key] = merged
+}
@@ -0,0 +1,350 @@
+package runner
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	"github.com/wjlin0/uncover/sources"
+	util "github.com/wjlin0/uncover/utils"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultStoreBatchSize is the number of results written per transaction
+var DefaultStoreBatchSize = 500
+
+const storeSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          TEXT PRIMARY KEY,
+	started_at  INTEGER NOT NULL,
+	finished_at INTEGER,
+	results     INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS results (
+	run_id  TEXT NOT NULL,
+	query   TEXT NOT NULL,
+	source  TEXT NOT NULL,
+	ip      TEXT NOT NULL,
+	port    INTEGER NOT NULL,
+	host    TEXT NOT NULL,
+	url     TEXT NOT NULL,
+	seen_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_asset ON results (ip, port, host);
+CREATE INDEX IF NOT EXISTS results_host ON results (host);
+CREATE INDEX IF NOT EXISTS results_seen_at ON results (seen_at);
+CREATE INDEX IF NOT EXISTS results_run_id ON results (run_id);
+`
+
+// Store records results in an sqlite database to keep their history between runs
+type Store struct {
+	db      *sql.DB
+	tx      *sql.Tx
+	insert  *sql.Stmt
+	pending int
+	run     string
+	count   int
+	sync.Mutex
+}
+
+// StoredAsset is an ip, port and host with the time it was first and last seen
+type StoredAsset struct {
+	IP        string    `json:"ip"`
+	Port      int       `json:"port"`
+	Host      string    `json:"host"`
+	Sources   []string  `json:"sources"`
+	Runs      int       `json:"runs"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// StoredResult is a result recorded by a run
+type StoredResult struct {
+	sources.Result
+	Run    string    `json:"run"`
+	SeenAt time.Time `json:"seen_at"`
+}
+
+// StoredRun is a recorded run of uncover
+type StoredRun struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Results    int       `json:"results"`
+}
+
+// StoreFilter selects results of the store, empty fields match everything.
+// Host, IP, Source and Query accept * as wildcard
+type StoreFilter struct {
+	Host   string
+	IP     string
+	Port   int
+	Source string
+	Query  string
+	Run    string
+	Since  time.Time
+	Until  time.Time
+	// New keeps the assets first seen since Since only
+	New   bool
+	Limit int
+}
+
+// OpenStore opens or creates the sqlite database at path
+func OpenStore(path string) (*Store, error) {
+	if err := fileutil.CreateFolders(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path))
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not open database %s", path)
+	}
+	// a single connection serializes the writes of concurrent runs
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(storeSchema); err != nil {
+		_ = db.Close()
+		return nil, errorutil.NewWithErr(err).Msgf("could not create database schema in %s", path)
+	}
+	return &Store{db: db}, nil
+}
+
+// BeginRun starts recording a new run and returns its id
+func (s *Store) BeginRun() (string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.flush(); err != nil {
+		return "", err
+	}
+	s.run = fmt.Sprintf("%s-%s", time.Now().Format("20060102T150405"), util.RandStr(6))
+	s.count = 0
+	_, err := s.db.Exec(`INSERT INTO runs (id, started_at) VALUES (?, ?)`, s.run, time.Now().Unix())
+	return s.run, err
+}
+
+// Record records result in the current run
+func (s *Store) Record(result sources.Result) error {
+	if result.Error != nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.run == "" {
+		return errorutil.New("no run started")
+	}
+	if s.tx == nil {
+		var err error
+		if s.tx, err = s.db.Begin(); err != nil {
+			return err
+		}
+		if s.insert, err = s.tx.Prepare(`INSERT INTO results (run_id, query, source, ip, port, host, url, seen_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`); err != nil {
+			_ = s.tx.Rollback()
+			s.tx = nil
+			return err
+		}
+	}
+	seenAt := result.Timestamp
+	if seenAt == 0 {
+		seenAt = time.Now().Unix()
+	}
+	if _, err := s.insert.Exec(s.run, result.Query, result.Source, result.IP, result.Port, strings.ToLower(result.Host), result.Url, seenAt); err != nil {
+		return err
+	}
+	s.count++
+	s.pending++
+	if s.pending >= DefaultStoreBatchSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// FinishRun commits the results of the current run
+func (s *Store) FinishRun() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if s.run == "" {
+		return nil
+	}
+	_, err := s.db.Exec(`UPDATE runs SET finished_at = ?, results = ? WHERE id = ?`, time.Now().Unix(), s.count, s.run)
+	s.run = ""
+	return err
+}
+
+func (s *Store) flush() error {
+	if s.tx == nil {
+		return nil
+	}
+	_ = s.insert.Close()
+	err := s.tx.Commit()
+	s.tx, s.insert, s.pending = nil, nil, 0
+	return err
+}
+
+// Assets returns the assets matching filter ordered by the time they were first seen
+func (s *Store) Assets(filter StoreFilter) ([]StoredAsset, error) {
+	where, args := filter.where()
+	query := `SELECT ip, port, host, GROUP_CONCAT(DISTINCT source), COUNT(DISTINCT run_id), MIN(seen_at), MAX(seen_at) FROM results` +
+		where + ` GROUP BY ip, port, host`
+	var having []string
+	if !filter.Since.IsZero() {
+		if filter.New {
+			having = append(having, "MIN(seen_at) >= ?")
+		} else {
+			having = append(having, "MAX(seen_at) >= ?")
+		}
+		args = append(args, filter.Since.Unix())
+	}
+	if !filter.Until.IsZero() {
+		having = append(having, "MIN(seen_at) <= ?")
+		args = append(args, filter.Until.Unix())
+	}
+	if len(having) > 0 {
+		query += " HAVING " + strings.Join(having, " AND ")
+	}
+	query += " ORDER BY MIN(seen_at), ip, port, host" + filter.limit()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []StoredAsset
+	for rows.Next() {
+		var (
+			asset               StoredAsset
+			sourceList          string
+			firstSeen, lastSeen int64
+		)
+		if err := rows.Scan(&asset.IP, &asset.Port, &asset.Host, &sourceList, &asset.Runs, &firstSeen, &lastSeen); err != nil {
+			return nil, err
+		}
+		asset.Sources = strings.Split(sourceList, ",")
+		asset.FirstSeen, asset.LastSeen = time.Unix(firstSeen, 0), time.Unix(lastSeen, 0)
+		assets = append(assets, asset)
+	}
+	return assets, rows.Err()
+}
+
+// History returns the recorded results matching filter ordered by the time they were seen
+func (s *Store) History(filter StoreFilter) ([]StoredResult, error) {
+	where, args := filter.where()
+	if !filter.Since.IsZero() {
+		where, args = appendCondition(where, "seen_at >= ?"), append(args, filter.Since.Unix())
+	}
+	if !filter.Until.IsZero() {
+		where, args = appendCondition(where, "seen_at <= ?"), append(args, filter.Until.Unix())
+	}
+	rows, err := s.db.Query(`SELECT run_id, query, source, ip, port, host, url, seen_at FROM results`+where+` ORDER BY seen_at, rowid`+filter.limit(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []StoredResult
+	for rows.Next() {
+		var (
+			result StoredResult
+			seenAt int64
+		)
+		if err := rows.Scan(&result.Run, &result.Query, &result.Source, &result.IP, &result.Port, &result.Host, &result.Url, &seenAt); err != nil {
+			return nil, err
+		}
+		result.Timestamp = seenAt
+		result.SeenAt = time.Unix(seenAt, 0)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// Runs returns the recorded runs, the latest first
+func (s *Store) Runs(limit int) ([]StoredRun, error) {
+	query := `SELECT id, started_at, COALESCE(finished_at, 0), results FROM runs ORDER BY started_at DESC, rowid DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []StoredRun
+	for rows.Next() {
+		var (
+			run                   StoredRun
+			startedAt, finishedAt int64
+		)
+		if err := rows.Scan(&run.ID, &startedAt, &finishedAt, &run.Results); err != nil {
+			return nil, err
+		}
+		run.StartedAt = time.Unix(startedAt, 0)
+		if finishedAt > 0 {
+			run.FinishedAt = time.Unix(finishedAt, 0)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Close commits pending results and closes the database
+func (s *Store) Close() error {
+	if err := s.FinishRun(); err != nil {
+		_ = s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
+
+// where returns the where clause of the non time based conditions of filter
+func (f StoreFilter) where() (string, []interface{}) {
+	var (
+		where string
+		args  []interface{}
+	)
+	match := func(column, value string) {
+		if value == "" {
+			return
+		}
+		if strings.Contains(value, "*") {
+			where = appendCondition(where, column+" LIKE ?")
+			args = append(args, strings.ReplaceAll(value, "*", "%"))
+			return
+		}
+		where = appendCondition(where, column+" = ?")
+		args = append(args, value)
+	}
+	match("host", strings.ToLower(f.Host))
+	match("ip", f.IP)
+	match("source", f.Source)
+	match("query", f.Query)
+	match("run_id", f.Run)
+	if f.Port > 0 {
+		where = appendCondition(where, "port = ?")
+		args = append(args, f.Port)
+	}
+	return where, args
+}
+
+func (f StoreFilter) limit() string {
+	if f.Limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" LIMIT %d", f.Limit)
+}
+
+func appendCondition(where, condition string) string {
+	if where == "" {
+		return " WHERE " + condition
+	}
+	return where + " AND " + condition
+}
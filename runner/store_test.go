@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func TestStore(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "uncover.db"))
+	require.Nil(t, err)
+	defer store.Close()
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first, err := store.BeginRun()
+	require.Nil(t, err)
+	require.Nil(t, store.Record(sources.Result{Timestamp: day.Unix(), Source: "fofa", Query: `domain="example.com"`, IP: "1.1.1.1", Port: 443, Host: "WWW.example.com"}))
+	require.Nil(t, store.FinishRun())
+
+	_, err = store.BeginRun()
+	require.Nil(t, err)
+	require.Nil(t, store.Record(sources.Result{Timestamp: day.Add(48 * time.Hour).Unix(), Source: "shodan", IP: "1.1.1.1", Port: 443, Host: "www.example.com"}))
+	require.Nil(t, store.Record(sources.Result{Timestamp: day.Add(48 * time.Hour).Unix(), Source: "fofa", IP: "2.2.2.2", Port: 22, Host: "ssh.example.org"}))
+	require.Nil(t, store.FinishRun())
+
+	assets, err := store.Assets(StoreFilter{Host: "*.example.com"})
+	require.Nil(t, err)
+	require.Len(t, assets, 1)
+	require.Equal(t, day.Unix(), assets[0].FirstSeen.Unix())
+	require.Equal(t, day.Add(48*time.Hour).Unix(), assets[0].LastSeen.Unix())
+	require.Equal(t, 2, assets[0].Runs)
+	require.ElementsMatch(t, []string{"fofa", "shodan"}, assets[0].Sources)
+
+	assets, err = store.Assets(StoreFilter{Since: day.Add(24 * time.Hour), New: true})
+	require.Nil(t, err)
+	require.Len(t, assets, 1, "only the asset first seen after since is new")
+	require.Equal(t, "2.2.2.2", assets[0].IP)
+
+	history, err := store.History(StoreFilter{Run: first})
+	require.Nil(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, `domain="example.com"`, history[0].Query)
+
+	runs, err := store.Runs(0)
+	require.Nil(t, err)
+	require.Len(t, runs, 2)
+	require.ElementsMatch(t, []int{1, 2}, []int{runs[0].Results, runs[1].Results})
+}
+
+func TestParseTime(t *testing.T) {
+	date, err := ParseTime("2024-01-02")
+	require.Nil(t, err)
+	require.Equal(t, 2, date.Day())
+
+	ago, err := ParseTime("7d")
+	require.Nil(t, err)
+	require.WithinDuration(t, time.Now().Add(-7*24*time.Hour), ago, time.Minute)
+
+	_, err = ParseTime("yesterday")
+	require.NotNil(t, err)
+}
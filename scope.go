@@ -0,0 +1,88 @@
+package uncover
+
+import (
+	"net"
+	"strings"
+
+	iputil "github.com/projectdiscovery/utils/ip"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// Scope is a list of domains, ips and cidrs, an empty scope contains everything
+type Scope struct {
+	domains []string
+	nets    []*net.IPNet
+}
+
+// NewScope parses the domains, ips and cidrs of values, *. prefixes of domains are ignored
+func NewScope(values []string) *Scope {
+	s := &Scope{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch {
+		case iputil.IsCIDR(value):
+			_, ipNet, _ := net.ParseCIDR(value)
+			s.nets = append(s.nets, ipNet)
+		case iputil.IsIP(value):
+			ip := net.ParseIP(value)
+			s.nets = append(s.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+		case value != "":
+			s.domains = append(s.domains, strings.TrimPrefix(value, "*."))
+		}
+	}
+	return s
+}
+
+// Empty is true when the scope has no domain, ip or cidr
+func (s *Scope) Empty() bool {
+	return len(s.domains) == 0 && len(s.nets) == 0
+}
+
+// ContainsResult is true when the scope is empty or the host or ip of result is in scope
+func (s *Scope) ContainsResult(result sources.Result) bool {
+	if s.Empty() {
+		return true
+	}
+	return s.ContainsDomain(result.Host) || s.ContainsIP(net.ParseIP(result.IP))
+}
+
+// ContainsTarget checks domain and ip pivots against the scope, other kinds
+// are derived from a result already in scope
+func (s *Scope) ContainsTarget(target sources.Target) bool {
+	if s.Empty() {
+		return true
+	}
+	switch target.Type {
+	case sources.TargetDomain:
+		return s.ContainsDomain(target.Value)
+	case sources.TargetCIDR:
+		ip, _, err := net.ParseCIDR(target.Value)
+		return err == nil && s.ContainsIP(ip)
+	default:
+		return true
+	}
+}
+
+// ContainsDomain is true when domain is a scope domain or one of its subdomains
+func (s *Scope) ContainsDomain(domain string) bool {
+	domain = strings.ToLower(domain)
+	for _, scope := range s.domains {
+		if domain == scope || strings.HasSuffix(domain, "."+scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsIP is true when ip is in a scope cidr
+func (s *Scope) ContainsIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range s.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
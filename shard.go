@@ -0,0 +1,173 @@
+package uncover
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// DefaultMaxShards is the maximum number of shards a query is split into
+var DefaultMaxShards = 500
+
+// minShardWindow is the smallest time range halved when sharding on time, engines filter by day
+const minShardWindow = 48 * time.Hour
+
+// queryAgent returns the results of query on agent. queries whose limit is over the result
+// cap of the engine are split into shards fitting under it, their results are merged
+// and duplicates are dropped
+func (s *Service) queryAgent(ctx context.Context, agent sources.Agent, query *sources.Query) (chan sources.Result, error) {
+	paging, _ := sources.PagingOf(agent)
+	maxResults := paging.MaxResults
+	counter, ok := agent.(sources.Counter)
+	if s.Options.DisableSharding || !ok || maxResults <= 0 || query.Limit <= maxResults {
+		return agent.Query(s.Session, query)
+	}
+	// shards are queries of their own, the pages of an interrupted query don't apply to them
+	query.Page = 0
+	results := make(chan sources.Result)
+	go func() {
+		defer close(results)
+
+		budget := DefaultMaxShards
+		shards := s.shards(agent, counter, query, -1, maxResults, sources.ShardFields, &budget)
+		gologger.Verbose().Msgf("Split %s query %s into %d shards\n", agent.Name(), query.Query, len(shards))
+		seen := make(map[string]struct{})
+		for _, shard := range shards {
+			if len(seen) >= query.Limit {
+				return
+			}
+			shard.Limit = query.Limit - len(seen)
+			if shard.Limit > maxResults {
+				shard.Limit = maxResults
+			}
+			ch, err := agent.Query(s.Session, shard)
+			if err != nil {
+				results <- sources.Result{Source: agent.Name(), Error: err}
+				continue
+			}
+			for result := range ch {
+				if result.Error == nil {
+					key := fmt.Sprintf("%s|%d|%s", result.IP, result.Port, result.Host)
+					if _, ok := seen[key]; ok {
+						continue
+					}
+					seen[key] = struct{}{}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case results <- result:
+				}
+			}
+		}
+	}()
+	return results, nil
+}
+
+// shard is a part of a split query and its number of results, -1 when it isn't known
+type shard struct {
+	query *sources.Query
+	total int
+}
+
+// shards splits query on the first of fields until each shard has at most maxResults
+// results, shards without results are dropped. total is the number of results of query,
+// it is counted when -1
+func (s *Service) shards(agent sources.Agent, counter sources.Counter, query *sources.Query, total, maxResults int, fields []string, budget *int) []*sources.Query {
+	if total < 0 {
+		var err error
+		if total, err = counter.Count(s.Session, query); err != nil {
+			gologger.Warning().Label(agent.Name()).Msgf("Could not count results of %s, not sharding it: %s\n", query.Query, err)
+			return []*sources.Query{query}
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	if total <= maxResults || len(fields) == 0 || *budget <= 0 {
+		return []*sources.Query{query}
+	}
+	parts := s.splitQuery(agent, query, fields[0])
+	if len(parts) == 0 {
+		return s.shards(agent, counter, query, total, maxResults, fields[1:], budget)
+	}
+	next := fields[1:]
+	if fields[0] == "time" {
+		// time windows are halved until they fit
+		next = fields
+	}
+	var shards []*sources.Query
+	for _, part := range parts {
+		*budget--
+		shards = append(shards, s.shards(agent, counter, part.query, part.total, maxResults, next, budget)...)
+	}
+	return shards
+}
+
+// splitQuery returns the queries of the values of field on agent, nil when it can't be split on field
+func (s *Service) splitQuery(agent sources.Agent, query *sources.Query, field string) []shard {
+	if field == "time" {
+		if !sources.SupportsTimeRange(agent.Name()) {
+			return nil
+		}
+		since, until := query.Since, query.Until
+		if until.IsZero() {
+			until = time.Now()
+		}
+		first, second := *query, *query
+		if since.IsZero() {
+			// without a start the oldest results are left in an open ended window, split again
+			// when it is over the cap. windows reaching before the oldest results are halved
+			if middle := until.AddDate(-1, 0, 0); middle.After(sources.DefaultOldestResult) {
+				first.Until = middle
+				second.Since, second.Until = middle, until
+				return []shard{{query: &first, total: -1}, {query: &second, total: -1}}
+			}
+			since = sources.DefaultOldestResult
+		}
+		if until.Sub(since) < minShardWindow {
+			return nil
+		}
+		middle := since.Add(until.Sub(since) / 2)
+		first.Since, first.Until = since, middle
+		second.Since, second.Until = middle, until
+		return []shard{{query: &first, total: -1}, {query: &second, total: -1}}
+	}
+	faceter, ok := agent.(sources.Faceter)
+	if !ok || !sliceutil.Contains(faceter.FacetFields(), field) {
+		return nil
+	}
+	facets, err := faceter.Facets(s.Session, query, field)
+	if err != nil {
+		gologger.Warning().Label(agent.Name()).Msgf("Could not split %s on %s: %s\n", query.Query, field, err)
+		return nil
+	}
+	var parts []shard
+	values := make([]string, 0, len(facets))
+	for _, facet := range facets {
+		value := facet.Filter
+		if value == "" {
+			value = facet.Value
+		}
+		q, ok := sources.ShardQuery(agent.Name(), query.Query, field, value)
+		if !ok {
+			return nil
+		}
+		part := *query
+		part.Query = q
+		// the counts of the aggregation api spare counting the shards again
+		parts = append(parts, shard{query: &part, total: facet.Count})
+		values = append(values, value)
+	}
+	// facets only return the most common values, the other ones are in a shard of their own
+	if q, ok := sources.ExcludeQuery(agent.Name(), query.Query, field, values); ok {
+		rest := *query
+		rest.Query = q
+		parts = append(parts, shard{query: &rest, total: -1})
+	}
+	return parts
+}
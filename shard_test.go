@@ -0,0 +1,109 @@
+package uncover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+// shardAgent has 25000 results, 15000 of them in US of which 9000 are on port 80,
+// 500 results of each query are on values other than the ones of its facets
+func shardAgent() *fakeAgent {
+	return &fakeAgent{
+		paging: sources.Paging{PageSize: 100, MaxResults: 10000},
+		count: func(query string) int {
+			switch {
+			case strings.Contains(query, "!="):
+				return 500
+			case strings.Contains(query, `port="80"`):
+				return 9000
+			case strings.Contains(query, `port="443"`):
+				return 6000
+			case strings.Contains(query, `country="US"`):
+				return 15000
+			case strings.Contains(query, `country="CN"`):
+				return 8000
+			}
+			return 25000
+		},
+		facets: map[string][]sources.Facet{
+			"country": {{Value: "United States", Filter: "US", Count: 15000}, {Value: "China", Filter: "CN", Count: 8000}},
+			"port":    {{Value: "80", Count: 9000}, {Value: "443", Count: 6000}},
+		},
+		results: func(query *sources.Query, n int) []sources.Result {
+			// the first result of every shard is the same asset
+			results := []sources.Result{{IP: "10.0.0.1", Port: 80}}
+			for i := 1; i < 3 && i < query.Limit; i++ {
+				results = append(results, sources.Result{IP: fmt.Sprintf("10.0.%d.%d", n, i), Port: 80})
+			}
+			return results
+		},
+	}
+}
+
+// shardFakeEngine makes the fake engine filter on fields and time until the end of the test
+func shardFakeEngine(t *testing.T) {
+	sources.DefaultShardSyntax[fakeEngine] = sources.ShardSyntax{
+		And: `(%s) && %s`, Filters: map[string]string{"country": `country="%s"`, "port": `port="%s"`},
+		AndNot: `(%s) && %s`, Excludes: map[string]string{"country": `country!="%s"`, "port": `port!="%s"`},
+	}
+	engines := sources.TimeRangeEngines
+	sources.TimeRangeEngines = append(engines[:len(engines):len(engines)], fakeEngine)
+	t.Cleanup(func() {
+		delete(sources.DefaultShardSyntax, fakeEngine)
+		sources.TimeRangeEngines = engines
+	})
+}
+
+func TestQueryAgentShards(t *testing.T) {
+	shardFakeEngine(t)
+	agent := shardAgent()
+	s := newFakeService(agent, &Options{})
+	ch, err := s.queryAgent(context.Background(), agent, &sources.Query{Query: `app="nginx"`, Limit: 20000})
+	require.Nil(t, err)
+	var ips []string
+	for result := range ch {
+		ips = append(ips, result.IP)
+	}
+	require.Equal(t, []string{
+		`((app="nginx") && country="US") && port="80"`,
+		`((app="nginx") && country="US") && port="443"`,
+		`(((app="nginx") && country="US") && port!="80") && port!="443"`,
+		`(app="nginx") && country="CN"`,
+		`((app="nginx") && country!="US") && country!="CN"`,
+	}, agent.queries)
+	require.Len(t, ips, 11, "duplicates of shards are dropped")
+	require.Equal(t, []string{
+		`app="nginx"`,
+		`(((app="nginx") && country="US") && port!="80") && port!="443"`,
+		`((app="nginx") && country!="US") && country!="CN"`,
+	}, agent.counted, "shards counted by facets are not counted again")
+
+	agent = shardAgent()
+	ch, err = s.queryAgent(context.Background(), agent, &sources.Query{Query: `app="nginx"`, Limit: 5000})
+	require.Nil(t, err)
+	for range ch {
+	}
+	require.Equal(t, []string{`app="nginx"`}, agent.queries, "limits under the cap are not sharded")
+
+	// without -since the oldest results are in an open ended window
+	until := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	parts := s.splitQuery(agent, &sources.Query{Query: `app="nginx"`, Until: until}, "time")
+	require.Len(t, parts, 2)
+	require.True(t, parts[0].query.Since.IsZero())
+	require.Equal(t, until.AddDate(-1, 0, 0), parts[0].query.Until)
+	require.Equal(t, until, parts[1].query.Until)
+	// the open ended window stops at the oldest results of the engines
+	until = sources.DefaultOldestResult.AddDate(0, 6, 0)
+	parts = s.splitQuery(agent, &sources.Query{Query: `app="nginx"`, Until: until}, "time")
+	require.Len(t, parts, 2)
+	require.Equal(t, sources.DefaultOldestResult, parts[0].query.Since)
+	require.Equal(t, parts[0].query.Until, parts[1].query.Since)
+	parts = s.splitQuery(agent, &sources.Query{Query: `app="nginx"`, Until: sources.DefaultOldestResult}, "time")
+	require.Empty(t, parts)
+}
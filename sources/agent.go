@@ -1,8 +1,28 @@
 package sources
 
+import "time"
+
 type Query struct {
 	Query string
 	Limit int
+	// Since and Until restrict results to the ones seen in this time range, zero times are not bounds
+	Since time.Time
+	Until time.Time
+	// Options are the engine specific options of the agent, validated against its schema
+	Options map[string]string
+	// PageSize is the page size of the provider config, 0 is the default of the agent
+	PageSize int
+	// Page is the number of pages of an interrupted query already fetched, the agents
+	// paging by page number start after them
+	Page int
+}
+
+// PageSizeOr returns the page size of the query, size when it has none
+func (query *Query) PageSizeOr(size int) int {
+	if query.PageSize > 0 {
+		return query.PageSize
+	}
+	return size
 }
 
 type Agent interface {
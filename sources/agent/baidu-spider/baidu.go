@@ -2,14 +2,25 @@ package baidu_spider
 
 import (
 	"fmt"
-	"github.com/wjlin0/uncover/sources"
 	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wjlin0/uncover/sources"
+	"github.com/wjlin0/uncover/sources/searchengine"
 )
 
 const (
 	URL     = "https://www.baidu.com/s?wd=%s&pn=%d&rn=%d"
 	URLInit = "https://www.baidu.com/"
 	Source  = "baidu-spider"
+	PerPage = 50
+	// MaxQueryLength is the length after which baidu truncates queries
+	MaxQueryLength = 76
+	// redirectDomainLength is the length of the domains baidu shortens in results, their
+	// subdomains are read from the redirect of the result links instead
+	redirectDomainLength = 12
+	redirectLinks        = "//div[@class=\"c-row source_1Vdff OP_LOG_LINK c-gap-top-xsmall source_s_3aixw \"]/a/@href"
 )
 
 type Agent struct {
@@ -19,70 +30,89 @@ type Agent struct {
 func (agent *Agent) Name() string {
 	return Source
 }
+
+// Capabilities are the kinds of targets baidu-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
-
-		cookies, err := agent.queryCookies(session)
-		if err != nil {
-			results <- sources.Result{Source: agent.Name(), Error: err}
-			return
-		}
-		newQuery(session, cookies, agent, query, results).run()
-
+		searchengine.Search(session, Engine(session, query.Query), query, results)
 	}()
 
 	return results, nil
 }
 
-func (agent *Agent) queryURL(session *sources.Session, URL string, cookies []*http.Cookie, baidu *baiduRequest) (*http.Response, error) {
-
-	URL = fmt.Sprintf(URL, baidu.Wd, baidu.Pn, baidu.Rn)
-
-	request, err := sources.NewHTTPRequest(http.MethodGet, URL, nil)
-	if err != nil {
-		return nil, err
+// Engine returns the baidu search engine of domain, session resolves the redirect links
+// of long domains
+func Engine(session *sources.Session, domain string) *searchengine.Engine {
+	var parser searchengine.Parser = &searchengine.RegexParser{Unescape: true}
+	if len(domain) > redirectDomainLength {
+		parser = &searchengine.XPathParser{
+			Expr: redirectLinks,
+			Resolve: func(link string) []string {
+				return matchLocation(session, domain, link)
+			},
+		}
 	}
-	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	request.Header.Add("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
-	request.Header.Add("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
-	//request.Header.Add("Accept-Encoding", "gzip, deflate, br")
-	request.Header.Add("Connection", "close")
-	request.Header.Add("Cache-Control", "max-age=0")
-	request.Header.Add("Upgrade-Insecure-Requests", "1")
-	request.Header.Add("Referer", URLInit)
-	for _, cookie := range cookies {
-		request.AddCookie(cookie)
+	return &searchengine.Engine{
+		Name:    Source,
+		InitURL: URLInit,
+		SearchURL: func(page searchengine.Page) string {
+			return fmt.Sprintf(URL, url.QueryEscape(page.Query), page.Offset, page.PerPage)
+		},
+		Headers: map[string]string{
+			"User-Agent":                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+			"Accept-Language":           "zh-CN,zh;q=0.9,en;q=0.8",
+			"Connection":                "close",
+			"Cache-Control":             "max-age=0",
+			"Upgrade-Insecure-Requests": "1",
+			"Referer":                   URLInit,
+		},
+		Cookies: []*http.Cookie{{
+			Name:   "kleck",
+			Value:  "6408666a6bc3e6a59bfa7b1ffcb4d094",
+			Path:   "/",
+			Domain: ".baidu.com",
+			MaxAge: 86400,
+		}},
+		PerPage:        PerPage,
+		MaxQueryLength: MaxQueryLength,
+		Parser:         parser,
+		HasNext: func(body string, page searchengine.Page) bool {
+			return strings.Contains(body, fmt.Sprintf("&pn=%d&", page.Offset))
+		},
+		BlockRules: []sources.BlockRule{
+			{Reason: "captcha", Location: "wappass.baidu.com"},
+			{Reason: "captcha", Body: "<title>百度安全验证</title>"},
+		},
 	}
-	return session.Do(request, agent.Name())
 }
 
-func (agent *Agent) queryCookies(session *sources.Session) ([]*http.Cookie, error) {
-	request, err := sources.NewHTTPRequest(http.MethodGet, URLInit, nil)
+// matchLocation returns the subdomains of domain in the redirect of a result link
+func matchLocation(session *sources.Session, domain string, link string) []string {
+	request, err := sources.NewHTTPRequest(http.MethodHead, link, nil)
 	if err != nil {
-		return nil, err
+		return nil
 	}
-	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	request.Header.Add("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
-	request.Header.Add("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
-	//request.Header.Add("Accept-Encoding", "gzip, deflate, br")
-	request.Header.Add("Connection", "close")
-	request.Header.Add("Cache-Control", "max-age=0")
-	request.Header.Add("Upgrade-Insecure-Requests", "1")
-	request.Header.Set("Referer", URLInit)
-
 	resp, err := session.Do(request, Source)
 	if err != nil {
-		return nil, err
+		return nil
+	}
+	resp.Body.Close()
+	location := resp.Header.Get("Location")
+	switch {
+	case location == "":
+		return nil
+	case strings.HasPrefix(location, "/"):
+		return sources.MatchSubdomains(domain, link, true)
+	default:
+		return sources.MatchSubdomains(domain, location, true)
 	}
-	return append(resp.Cookies(), &http.Cookie{
-		Name:   "kleck",
-		Value:  "6408666a6bc3e6a59bfa7b1ffcb4d094",
-		Path:   "/",
-		Domain: ".baidu.com",
-		MaxAge: 86400,
-	}), nil
 }
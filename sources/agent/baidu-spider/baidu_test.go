@@ -0,0 +1,42 @@
+package baidu_spider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+	"github.com/wjlin0/uncover/sources/searchengine"
+)
+
+func TestParse(t *testing.T) {
+	body, err := os.ReadFile("testdata/results.html")
+	require.Nil(t, err)
+	session, err := sources.NewSession(&sources.Keys{}, 0, 5, 60, []string{Source}, time.Second, "", "")
+	require.Nil(t, err)
+	engine := Engine(session, "example.com")
+
+	hosts := engine.Parser.Parse("example.com", body)
+	sort.Strings(hosts)
+	require.Equal(t, []string{"bbs.example.com", "www.example.com"}, hosts)
+	require.True(t, engine.HasNext(string(body), searchengine.Page{Offset: PerPage}))
+
+	// the subdomains of long domains are read from the redirect of the result links
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		location := "/s?wd=relative"
+		if r.URL.Query().Get("url") == "aaa" {
+			location = "https://www.corp.example.com/"
+		}
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer ts.Close()
+	body = []byte(strings.ReplaceAll(string(body), "http://www.baidu.com", ts.URL))
+	engine = Engine(session, "corp.example.com")
+	require.Equal(t, []string{"www.corp.example.com"}, engine.Parser.Parse("corp.example.com", body))
+}
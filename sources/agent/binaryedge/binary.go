@@ -25,6 +25,16 @@ const (
 func (agent *Agent) Name() string {
 	return Source
 }
+
+// Capabilities are the kinds of targets binaryedge searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
+// Paging is how binaryedge pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, PageCost: 1, Unit: "queries"}
+}
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.BinaryedgeToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -40,9 +50,9 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 			binaryRequest := &BinaryRequest{
 				Query:    query.Query,
 				Page:     currentPage,
-				PageSize: Size,
+				PageSize: query.PageSizeOr(Size),
 			}
-			if query.Limit > Size*5 {
+			if query.PageSize == 0 && query.Limit > Size*5 {
 				binaryRequest.PageSize = query.Limit / 5
 			}
 			binaryResponse := agent.query(session, binaryRequest, results)
@@ -2,33 +2,39 @@ package bing_spider
 
 import (
 	"fmt"
-	"github.com/corpix/uarand"
-	"github.com/wjlin0/uncover/sources"
 	"net/http"
+	"net/url"
 	"strings"
+
+	"github.com/corpix/uarand"
+	"github.com/wjlin0/uncover/sources"
+	"github.com/wjlin0/uncover/sources/searchengine"
 )
 
 const (
-	URL     = "https://www.bing.com/search?q=%s&first=%d"
-	URLCN   = "https://cn.bing.com/search?q=%s&first=%d"
+	URL     = "https://www.bing.com/search?q=%s&first=%d&count=%d"
+	URLCN   = "https://cn.bing.com/search?q=%s&first=%d&count=%d"
 	URLInit = "https://www.bing.com/"
 	Source  = "bing-spider"
 	Limit   = 100
+	PerPage = 10
+	// MaxQueryLength is the maximum length of a bing query
+	MaxQueryLength = 2000
 )
 
 type Agent struct {
 	options *sources.Agent
 }
 
-type bingRequest struct {
-	Q     string `json:"q"`
-	First int    `json:"first"`
-}
-
 func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets bing-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
@@ -41,27 +47,54 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 			results <- sources.Result{Source: agent.Name(), Error: err}
 			return
 		}
-		newQuery(session, cookies, agent, query, results, isCN).run()
+		// the endpoint is detected from the redirect of bing unless given
+		switch strings.ToLower(query.Options["endpoint"]) {
+		case "global":
+			isCN = false
+		case "cn":
+			isCN = true
+		}
+		engine := Engine(isCN)
+		engine.Cookies = cookies
+		searchengine.Search(session, engine, query, results)
 	}()
 
 	return results, nil
 }
-func (agent *Agent) queryURL(session *sources.Session, URL string, cookies []*http.Cookie, bingRequest *bingRequest) (*http.Response, error) {
 
-	bingURL := fmt.Sprintf(URL, bingRequest.Q, bingRequest.First)
-	request, err := sources.NewHTTPRequest(http.MethodGet, bingURL, nil)
-	if err != nil {
-		return nil, err
+// Engine returns the bing search engine, cn.bing.com when isCN is true
+func Engine(isCN bool) *searchengine.Engine {
+	U := URL
+	if isCN {
+		U = URLCN
 	}
-	request.Header.Set("Upgrade-Insecure-Requests", "1")
-	request.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
-	request.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	// 使用随机生成的User-Agent
-	request.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/97.0.4692.71 Safari/537.36ZaloTheme/light ZaloLanguage/en")
-	for _, cookie := range cookies {
-		request.AddCookie(cookie)
+	return &searchengine.Engine{
+		Name: Source,
+		SearchURL: func(page searchengine.Page) string {
+			searchURL := fmt.Sprintf(U, url.QueryEscape(page.Query), page.Offset, page.PerPage)
+			if page.Locale != "" {
+				searchURL += "&setmkt=" + url.QueryEscape(page.Locale)
+			}
+			return searchURL
+		},
+		Headers: map[string]string{
+			"Upgrade-Insecure-Requests": "1",
+			"Accept-Language":           "zh-CN,zh;q=0.9,en;q=0.8",
+			"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8",
+			"User-Agent":                "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/97.0.4692.71 Safari/537.36ZaloTheme/light ZaloLanguage/en",
+		},
+		PerPage:        PerPage,
+		MaxOffset:      Limit,
+		MaxQueryLength: MaxQueryLength,
+		Parser:         &searchengine.RegexParser{Unescape: true},
+		HasNext: func(body string, page searchengine.Page) bool {
+			return strings.Contains(body, "<div class=\"sw_next\">")
+		},
+		BlockRules: []sources.BlockRule{
+			{Reason: "captcha", Body: "/turing/captcha/"},
+			{Reason: "captcha", Location: "/turing/captcha/"},
+		},
 	}
-	return session.Do(request, agent.Name())
 }
 
 func (agent *Agent) queryCookies(session *sources.Session) ([]*http.Cookie, bool, error) {
@@ -76,9 +109,17 @@ func (agent *Agent) queryCookies(session *sources.Session) ([]*http.Cookie, bool
 	if err != nil {
 		return nil, false, err
 	}
+	resp.Body.Close()
 	if resp.StatusCode == 302 && strings.Contains(resp.Header.Get("Location"), "cn.bing.com") {
 		isCN = true
 	}
 
 	return resp.Cookies(), isCN, nil
 }
+
+func (agent *Agent) Options() []sources.Option {
+	return []sources.Option{
+		{Name: "endpoint", Description: "bing endpoint, detected from the redirect of bing by default", Values: []string{"auto", "global", "cn"}},
+		{Name: "locale", Description: "market of the results (example: en-US)"},
+	}
+}
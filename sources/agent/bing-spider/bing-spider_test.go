@@ -0,0 +1,21 @@
+package bing_spider
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources/searchengine"
+)
+
+func TestParse(t *testing.T) {
+	body, err := os.ReadFile("testdata/results.html")
+	require.Nil(t, err)
+	engine := Engine(false)
+
+	hosts := engine.Parser.Parse("example.com", body)
+	sort.Strings(hosts)
+	require.Equal(t, []string{"api.example.com", "status.example.com", "www.example.com"}, hosts)
+	require.True(t, engine.HasNext(string(body), searchengine.Page{Offset: PerPage}))
+}
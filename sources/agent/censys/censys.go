@@ -7,12 +7,13 @@ import (
 	util "github.com/wjlin0/uncover/utils"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/wjlin0/uncover/sources"
 )
 
 const (
-	URL        = "https://search.censys.io/api/v2/hosts/search?q=%s&per_page=%d&virtual_hosts=INCLUDE"
+	URL        = "https://search.censys.io/api/v2/hosts/search?q=%s&per_page=%d&virtual_hosts=%s"
 	MaxPerPage = 100
 	Source     = "censys"
 )
@@ -23,6 +24,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets censys searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
+// Paging is how censys pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: MaxPerPage, PageCost: 1, Unit: "queries"}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.CensysToken == "" || session.Keys.CensysSecret == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -37,9 +48,10 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 		nextCursor := ""
 		for {
 			censysRequest := &CensysRequest{
-				Query:   query.Query,
-				PerPage: MaxPerPage,
-				Cursor:  nextCursor,
+				Query:        query.Query,
+				PerPage:      query.PageSizeOr(MaxPerPage),
+				Cursor:       nextCursor,
+				VirtualHosts: strings.ToUpper(query.Options["virtual_hosts"]),
 			}
 			censysResponse := agent.query(URL, session, censysRequest, results)
 			if censysResponse == nil {
@@ -57,7 +69,12 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 }
 
 func (agent *Agent) queryURL(session *sources.Session, URL string, censysRequest *CensysRequest) (*http.Response, error) {
-	censysURL := fmt.Sprintf(URL, url.QueryEscape(censysRequest.Query), censysRequest.PerPage)
+	// virtual hosts are included unless told otherwise
+	virtualHosts := censysRequest.VirtualHosts
+	if virtualHosts == "" {
+		virtualHosts = "INCLUDE"
+	}
+	censysURL := fmt.Sprintf(URL, url.QueryEscape(censysRequest.Query), censysRequest.PerPage, virtualHosts)
 	if censysRequest.Cursor != "" {
 		censysURL += fmt.Sprintf("&cursor=%s", censysRequest.Cursor)
 	}
@@ -121,7 +138,31 @@ func (agent *Agent) query(URL string, session *sources.Session, censysRequest *C
 }
 
 type CensysRequest struct {
-	Query   string
-	PerPage int
-	Cursor  string
+	Query        string
+	PerPage      int
+	Cursor       string
+	VirtualHosts string
+}
+
+func (agent *Agent) Options() []sources.Option {
+	return []sources.Option{
+		{Name: "virtual_hosts", Description: "how virtual hosts are returned, include by default", Values: []string{"exclude", "include", "only"}},
+	}
+}
+
+// Count returns the total of query with a single result page
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.CensysToken == "" || session.Keys.CensysSecret == "" {
+		return 0, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	resp, err := agent.queryURL(session, URL, &CensysRequest{Query: query.Query, PerPage: 1, VirtualHosts: strings.ToUpper(query.Options["virtual_hosts"])})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	censysResponse := &CensysResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(censysResponse); err != nil {
+		return 0, err
+	}
+	return censysResponse.Results.Total, nil
 }
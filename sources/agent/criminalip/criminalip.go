@@ -21,6 +21,11 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets criminalip searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain, sources.TargetIP, sources.TargetRaw}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.CriminalIPToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -14,7 +14,9 @@ const (
 	URL    = "https://www.daydaymap.com/api/v1/raymap/search/all"
 	Fields = "ip,port,domain,service"
 	Size   = 100
-	Source = "daydaymap"
+	// LargeSize is the page size of limits over five pages
+	LargeSize = 500
+	Source    = "daydaymap"
 )
 
 type Agent struct{}
@@ -23,6 +25,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets daydaymap searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain, sources.TargetIP, sources.TargetRaw}
+}
+
+// Paging is how daydaymap pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, LargePageSize: LargeSize}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.DayDayMapToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -33,19 +45,20 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		var numberOfResults int
+		pageSize := query.PageSizeOr(Size)
+		if query.PageSize == 0 && query.Limit > Size*5 {
+			pageSize = LargeSize
+		}
+		numberOfResults := query.Page * pageSize
 
-		page := 1
+		page := query.Page + 1
 		for {
 			daymapRequest := &DayDayMapRequest{
 				Keyword:  query.Query,
 				Fields:   Fields,
-				PageSize: Size,
+				PageSize: pageSize,
 				Page:     page,
 			}
-			if query.Limit > Size*5 {
-				daymapRequest.PageSize = 500
-			}
 			daymapResponse := agent.query(URL, session, daymapRequest, results)
 			if daymapResponse == nil {
 				break
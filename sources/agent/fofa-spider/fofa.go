@@ -9,9 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/wjlin0/uncover/sources"
 	util "github.com/wjlin0/uncover/utils"
-	"io"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,20 +18,40 @@ import (
 
 const Source = "fofa-spider"
 
+// BlockRules match the captcha and protection pages fofa shows instead of results
+var BlockRules = []sources.BlockRule{
+	{Reason: "captcha", Body: "TencentCaptcha"},
+	{Reason: "interstitial", Body: "aliyun_waf"},
+	{Reason: "rate limit", Body: "请求过于频繁"},
+}
+
 type Agent struct{}
 
 func (agent *Agent) Name() string {
 	return Source
 }
+
+// Capabilities are the kinds of targets fofa-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
 	go func() {
 		defer close(results)
 
-		var numberOfResults int
+		var (
+			numberOfResults int
+			blocked         bool
+		)
 
 		list, err := agent.queryStatsList(stats, session, query)
+		if _, ok := sources.IsBlocked(err); ok {
+			results <- sources.Result{Source: agent.Name(), Error: err}
+			return
+		}
 		if err != nil {
 			results <- sources.Result{Source: agent.Name(), Error: errors.Wrap(err, "get fofa-spider error")}
 			return
@@ -45,9 +63,15 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 				wg.Add(1)
 				go func(q string) {
 					defer wg.Done()
-					spiderResult := agent.query(session, q, URL, query.Limit, results)
+					spiderResult, err := agent.query(session, q, URL, query.Limit, results)
 					lock.Lock()
 					numberOfResults += len(spiderResult)
+					// the regions searched alongside are blocked too, only the first block is sent
+					if blockedErr, ok := sources.IsBlocked(err); ok && !blocked {
+						blocked = true
+						blockedErr.Query = query.Query
+						results <- sources.Result{Source: agent.Name(), Error: blockedErr}
+					}
 					lock.Unlock()
 					if numberOfResults > query.Limit {
 						return
@@ -79,18 +103,20 @@ func (agent *Agent) queryStatsList(STATS string, session *sources.Session, query
 		return nil, err
 	}
 	request.Header.Set("Referer", "https://fofa.info/")
-	resp, err := session.Do(request, Source)
-	if err != nil {
-		return nil, err
+	retry := &sources.BlockRetry{Source: Source, Rules: BlockRules}
+	_, body, err := retry.Fetch(STATS, func() (*http.Response, error) {
+		return session.Do(request, Source)
+	})
+	if blocked, ok := sources.IsBlocked(err); ok {
+		blocked.Query = query.Query
+		return nil, blocked
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.NewDecoder(bytes.NewBuffer(body)).Decode(fofaResponse); err != nil {
+	if err := json.NewDecoder(bytes.NewBuffer(body.Bytes())).Decode(fofaResponse); err != nil {
 		var mapData map[string]interface{}
-		if err := json.NewDecoder(bytes.NewBuffer(body)).Decode(&mapData); err != nil {
+		if err := json.NewDecoder(bytes.NewBuffer(body.Bytes())).Decode(&mapData); err != nil {
 			return nil, err
 		}
 		if temp, ok := mapData["message"].(string); !ok {
@@ -107,10 +133,13 @@ func (agent *Agent) queryStatsList(STATS string, session *sources.Session, query
 	return fofaResponse, nil
 }
 
-func (agent *Agent) query(session *sources.Session, query string, URL string, limit int, result chan sources.Result) []sources.Result {
+// query sends the results of the region query page by page, a BlockedError is returned
+// when fofa keeps blocking its pages
+func (agent *Agent) query(session *sources.Session, query string, URL string, limit int, result chan sources.Result) ([]sources.Result, error) {
 	var (
 		spiderResult []sources.Result
 	)
+	retry := &sources.BlockRetry{Source: Source, Rules: BlockRules}
 	page := 1
 	for {
 		fofa := &fofaRequest{
@@ -118,14 +147,20 @@ func (agent *Agent) query(session *sources.Session, query string, URL string, li
 			Page:    page,
 			PageNum: 10,
 		}
-		resp, err := agent.queryURL(session, fofa, URL)
-		if err != nil {
-			continue
+		spiderURL := fmt.Sprintf(URL, fofa.Query, fofa.Page, fofa.PageNum)
+		resp, body, err := retry.Fetch(spiderURL, func() (*http.Response, error) {
+			return agent.queryURL(session, fofa, URL)
+		})
+		if blocked, ok := sources.IsBlocked(err); ok {
+			blocked.Results = len(spiderResult)
+			return spiderResult, blocked
 		}
-		body, err := sources.ReadBody(resp)
 		if err != nil || body == nil {
 			continue
 		}
+		if err := session.LoggedOut(Source, resp, body.String()); err != nil {
+			result <- sources.Result{Source: Source, Error: err}
+		}
 		rs := parseResult(*body)
 		for _, r := range rs {
 			result <- r
@@ -138,7 +173,7 @@ func (agent *Agent) query(session *sources.Session, query string, URL string, li
 		spiderResult = append(spiderResult, rs...)
 	}
 
-	return spiderResult
+	return spiderResult, nil
 }
 func (agent *Agent) queryURL(session *sources.Session, fofaRequest *fofaRequest, URL string) (*http.Response, error) {
 
@@ -147,9 +182,6 @@ func (agent *Agent) queryURL(session *sources.Session, fofaRequest *fofaRequest,
 	if err != nil {
 		return nil, err
 	}
-	if cookies := os.Getenv("FOFA_COOKIE"); cookies != "" {
-		request.Header.Set("Cookie", cookies)
-	}
 	request.Header.Set("Referer", URL)
 	return session.Do(request, agent.Name())
 }
@@ -190,3 +222,20 @@ func parseResult(body bytes.Buffer) (results []sources.Result) {
 	}
 	return results
 }
+
+func (agent *Agent) FacetFields() []string {
+	return []string{"country"}
+}
+
+// Facets counts the results of query by country with the stats list used to split queries
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, field string) ([]sources.Facet, error) {
+	list, err := agent.queryStatsList(stats, session, query)
+	if err != nil {
+		return nil, err
+	}
+	var facets []sources.Facet
+	for _, c := range list.Data.Countries {
+		facets = append(facets, sources.Facet{Field: field, Value: c.Name, Count: c.Count})
+	}
+	return facets, nil
+}
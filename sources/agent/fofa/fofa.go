@@ -8,15 +8,22 @@ import (
 	util "github.com/wjlin0/uncover/utils"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/wjlin0/uncover/sources"
 )
 
 const (
-	URL    = "https://fofa.info/api/v1/search/all?email=%s&key=%s&qbase64=%s&fields=%s&page=%d&size=%d"
-	Fields = "ip,port,host"
-	Size   = 100
-	Source = "fofa"
+	URL = "https://fofa.info/api/v1/search/all?email=%s&key=%s&qbase64=%s&fields=%s&page=%d&size=%d"
+	// StatsURL aggregates the results of a query on the given fields
+	StatsURL = "https://fofa.info/api/v1/search/stats?email=%s&key=%s&qbase64=%s&fields=%s"
+	Fields   = "ip,port,host"
+	Size     = 100
+	// LargeSize is the page size of limits over five pages
+	LargeSize = 500
+	// MaxResults is the most results fofa pages through for a query
+	MaxResults = 10000
+	Source     = "fofa"
 )
 
 type Agent struct{}
@@ -25,6 +32,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets fofa searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
+// Paging is how fofa pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, LargePageSize: LargeSize, ResultCost: 1, Unit: "results quota", MaxResults: MaxResults}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.FofaEmail == "" || session.Keys.FofaKey == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -35,18 +52,20 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		var numberOfResults int
+		pageSize := query.PageSizeOr(Size)
+		if query.PageSize == 0 && query.Limit > Size*5 {
+			pageSize = LargeSize
+		}
+		numberOfResults := query.Page * pageSize
 
-		page := 1
+		page := query.Page + 1
 		for {
 			fofaRequest := &FofaRequest{
-				Query:  query.Query,
-				Fields: Fields,
-				Size:   Size,
+				Query:  timeQuery(query),
+				Fields: fields(query),
+				Size:   pageSize,
 				Page:   page,
-			}
-			if query.Limit > Size*5 {
-				fofaRequest.Size = 500
+				Full:   strings.ToLower(query.Options["full"]),
 			}
 			fofaResponse := agent.query(URL, session, fofaRequest, results)
 			if fofaResponse == nil {
@@ -66,7 +85,10 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 func (agent *Agent) queryURL(session *sources.Session, URL string, fofaRequest *FofaRequest) (*http.Response, error) {
 	base64Query := base64.StdEncoding.EncodeToString([]byte(fofaRequest.Query))
-	fofaURL := fmt.Sprintf(URL, session.Keys.FofaEmail, session.Keys.FofaKey, base64Query, Fields, fofaRequest.Page, fofaRequest.Size)
+	fofaURL := fmt.Sprintf(URL, session.Keys.FofaEmail, session.Keys.FofaKey, base64Query, fofaRequest.Fields, fofaRequest.Page, fofaRequest.Size)
+	if fofaRequest.Full != "" {
+		fofaURL += "&full=" + fofaRequest.Full
+	}
 	request, err := sources.NewHTTPRequest(http.MethodGet, fofaURL, nil)
 	if err != nil {
 		return nil, err
@@ -95,7 +117,7 @@ func (agent *Agent) query(URL string, session *sources.Session, fofaRequest *Fof
 		return nil
 	}
 	if fofaResponse.Error {
-		results <- sources.Result{Source: agent.Name(), Error: fmt.Errorf(fofaResponse.ErrMsg)}
+		results <- sources.Result{Source: agent.Name(), Error: errors.New(fofaResponse.ErrMsg)}
 		return nil
 	}
 
@@ -110,6 +132,16 @@ func (agent *Agent) query(URL string, session *sources.Session, fofaRequest *Fof
 		}
 		result.Url = fmt.Sprintf("%s://%s:%d", protocol, host, result.Port)
 		raw, _ := json.Marshal(result)
+		if names := strings.Split(fofaRequest.Fields, ","); len(names) > 3 {
+			// custom fields are kept in the raw response by name
+			values := make(map[string]string, len(names))
+			for i, name := range names {
+				if i < len(fofaResult) {
+					values[name] = fofaResult[i]
+				}
+			}
+			raw, _ = json.Marshal(values)
+		}
 		result.Raw = raw
 		results <- result
 	}
@@ -123,3 +155,103 @@ type FofaRequest struct {
 	Size   int
 	Full   string
 }
+
+// statsFields are the fofa stats fields of the uncover facet fields
+var statsFields = map[string]string{
+	"port":    "port",
+	"country": "country",
+	"org":     "org",
+	"title":   "title",
+	"asn":     "asn",
+}
+
+func (agent *Agent) FacetFields() []string {
+	return []string{"port", "country", "org", "title", "asn"}
+}
+
+// Facets aggregates the results of query with the stats api
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, field string) ([]sources.Facet, error) {
+	if session.Keys.FofaEmail == "" || session.Keys.FofaKey == "" {
+		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	name := statsFields[field]
+	base64Query := base64.StdEncoding.EncodeToString([]byte(timeQuery(query)))
+	statsURL := fmt.Sprintf(StatsURL, session.Keys.FofaEmail, session.Keys.FofaKey, base64Query, name)
+	request, err := sources.NewHTTPRequest(http.MethodGet, statsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d received from fofa stats api", resp.StatusCode)
+	}
+	statsResponse := &FofaStatsResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(statsResponse); err != nil {
+		return nil, err
+	}
+	if statsResponse.Error {
+		return nil, errors.New(statsResponse.ErrMsg)
+	}
+	buckets := statsResponse.Aggs[name]
+	if name == "country" && len(buckets) == 0 {
+		buckets = statsResponse.Aggs["countries"]
+	}
+	var facets []sources.Facet
+	for _, bucket := range buckets {
+		facets = append(facets, sources.Facet{Field: field, Value: fmt.Sprint(bucket.Name), Count: bucket.Count, Filter: bucket.Code})
+	}
+	return facets, nil
+}
+
+// Count returns the size of query with a single result page
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.FofaEmail == "" || session.Keys.FofaKey == "" {
+		return 0, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	resp, err := agent.queryURL(session, URL, &FofaRequest{Query: timeQuery(query), Fields: Fields, Page: 1, Size: 1, Full: strings.ToLower(query.Options["full"])})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	fofaResponse := &FofaResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(fofaResponse); err != nil {
+		return 0, err
+	}
+	if fofaResponse.Error {
+		return 0, errors.New(fofaResponse.ErrMsg)
+	}
+	return fofaResponse.Size, nil
+}
+
+// timeQuery returns the query with the after and before filters of its time range
+func timeQuery(query *sources.Query) string {
+	q := query.Query
+	if !query.Since.IsZero() {
+		q = fmt.Sprintf(`(%s) && after="%s"`, q, query.Since.Format("2006-01-02"))
+	}
+	if !query.Until.IsZero() {
+		q = fmt.Sprintf(`(%s) && before="%s"`, q, query.Until.Format("2006-01-02"))
+	}
+	return q
+}
+
+// fields returns the fields of the results, ip, port and host followed by the custom fields of query
+func fields(query *sources.Query) string {
+	custom := strings.Trim(query.Options["fields"], ", ")
+	if custom == "" {
+		return Fields
+	}
+	return Fields + "," + custom
+}
+
+func (agent *Agent) Options() []sources.Option {
+	return []sources.Option{
+		{Name: "full", Description: "search all the data instead of the last year", Values: sources.BoolValues},
+		{Name: "fields", Description: "comma separated fields kept in the raw results (example: title,country)"},
+	}
+}
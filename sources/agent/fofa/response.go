@@ -10,3 +10,17 @@ type FofaResponse struct {
 	Results [][]string `json:"results"`
 	Size    int        `json:"size"`
 }
+
+// FofaStatsResponse contains the fofa stats response
+type FofaStatsResponse struct {
+	Error  bool                         `json:"error"`
+	ErrMsg string                       `json:"errmsg"`
+	Aggs   map[string][]FofaStatsBucket `json:"aggs"`
+}
+
+type FofaStatsBucket struct {
+	Count int         `json:"count"`
+	Name  interface{} `json:"name"`
+	// Code is the country code filtering queries on countries
+	Code string `json:"code"`
+}
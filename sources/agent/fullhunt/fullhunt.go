@@ -21,6 +21,11 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets fullhunt searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 type fullhuntRequest struct {
 	Domain string `json:"domain"`
 }
@@ -22,6 +22,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets github searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain, sources.TargetRaw}
+}
+
+// Paging is how github pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: PerPage}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.GithubToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -32,13 +42,13 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		var numberOfResults int
+		numberOfResults := query.Page * query.PageSizeOr(PerPage)
 
-		page := 1
+		page := query.Page + 1
 		for {
 			github := &githubRequest{
 				Query:   query.Query,
-				PerPage: PerPage,
+				PerPage: query.PageSizeOr(PerPage),
 				Page:    page,
 			}
 			githubResponse := agent.query(URL, session, github, results)
@@ -0,0 +1,22 @@
+package google_spider
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources/searchengine"
+)
+
+func TestParse(t *testing.T) {
+	body, err := os.ReadFile("testdata/results.html")
+	require.Nil(t, err)
+	engine := Engine()
+
+	hosts := engine.Parser.Parse("example.com", body)
+	sort.Strings(hosts)
+	require.Equal(t, []string{"dev.example.com", "example.com", "shop.example.com", "www.example.com"}, hosts)
+	require.True(t, engine.HasNext(string(body), searchengine.Page{Offset: PerPage}))
+	require.False(t, engine.HasNext(string(body), searchengine.Page{Offset: 2 * PerPage}))
+}
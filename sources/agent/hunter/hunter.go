@@ -8,12 +8,17 @@ import (
 	"github.com/wjlin0/uncover/sources"
 	util "github.com/wjlin0/uncover/utils"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 const (
-	URL    = "https://hunter.qianxin.com/openApi/search?api-key=%s&search=%s&page=%d&page_size=%d"
-	Size   = 100
-	Source = "hunter"
+	URL  = "https://hunter.qianxin.com/openApi/search?api-key=%s&search=%s&page=%d&page_size=%d"
+	Size = 100
+	// MaxResults is the most results hunter pages through for a query
+	MaxResults = 10000
+	Source     = "hunter"
 )
 
 type Agent struct{}
@@ -22,6 +27,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets hunter searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
+// Paging is how hunter pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, ResultCost: 1, Unit: "points", MaxResults: MaxResults}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.HunterToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -32,16 +47,20 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		numberOfResults := 0
+		numberOfResults := query.Page * query.PageSizeOr(Size)
 
-		page := 1
+		page := query.Page + 1
 		for {
 			hunterRequest := &Request{
-				ApiKey:   session.Keys.HunterToken,
-				Search:   query.Query,
-				Page:     page,
-				PageSize: Size,
+				ApiKey:     session.Keys.HunterToken,
+				Search:     query.Query,
+				Page:       page,
+				PageSize:   query.PageSizeOr(Size),
+				StartTime:  formatTime(query.Since),
+				EndTime:    formatTime(query.Until),
+				StatusCode: query.Options["status_code"],
 			}
+			hunterRequest.IsWeb, _ = strconv.Atoi(query.Options["is_web"])
 			hunterResponse := agent.query(URL, session, hunterRequest, results)
 			if hunterResponse == nil {
 				break
@@ -94,6 +113,18 @@ func (agent *Agent) query(URL string, session *sources.Session, hunterRequest *R
 func (agent *Agent) queryURL(session *sources.Session, URL string, hunterRequest *Request) (*http.Response, error) {
 	base64Query := base64.URLEncoding.EncodeToString([]byte(hunterRequest.Search))
 	hunterURL := fmt.Sprintf(URL, hunterRequest.ApiKey, base64Query, hunterRequest.Page, hunterRequest.PageSize)
+	if hunterRequest.StartTime != "" {
+		hunterURL += "&start_time=" + url.QueryEscape(hunterRequest.StartTime)
+	}
+	if hunterRequest.EndTime != "" {
+		hunterURL += "&end_time=" + url.QueryEscape(hunterRequest.EndTime)
+	}
+	if hunterRequest.IsWeb > 0 {
+		hunterURL += "&is_web=" + strconv.Itoa(hunterRequest.IsWeb)
+	}
+	if hunterRequest.StatusCode != "" {
+		hunterURL += "&status_code=" + url.QueryEscape(hunterRequest.StatusCode)
+	}
 	request, err := sources.NewHTTPRequest(http.MethodGet, hunterURL, nil)
 	if err != nil {
 		return nil, err
@@ -108,3 +139,40 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, hunterRequest
 	}
 	return resp, nil
 }
+
+// Count returns the total of query with a single result page
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.HunterToken == "" {
+		return 0, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	hunterRequest := &Request{ApiKey: session.Keys.HunterToken, Search: query.Query, Page: 1, PageSize: 1, StartTime: formatTime(query.Since), EndTime: formatTime(query.Until), StatusCode: query.Options["status_code"]}
+	hunterRequest.IsWeb, _ = strconv.Atoi(query.Options["is_web"])
+	resp, err := agent.queryURL(session, URL, hunterRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	hunterResponse := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(hunterResponse); err != nil {
+		return 0, err
+	}
+	if hunterResponse.Code != http.StatusOK {
+		return 0, fmt.Errorf("hunter search failed: %s", hunterResponse.Msg)
+	}
+	return hunterResponse.Data.Total, nil
+}
+
+// formatTime formats a bound of the time range as hunter dates, empty for no bound
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func (agent *Agent) Options() []sources.Option {
+	return []sources.Option{
+		{Name: "is_web", Description: "asset type, 1 web, 2 non web and 3 all", Values: []string{"1", "2", "3"}},
+		{Name: "status_code", Description: "comma separated http status codes of web assets (example: 200,401)"},
+	}
+}
@@ -21,6 +21,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets hunterhow searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain, sources.TargetIP, sources.TargetRaw}
+}
+
+// Paging is how hunterhow pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, PageCost: 1, Unit: "queries"}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.HunterHowToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -31,15 +41,17 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		numberOfResults := 0
+		numberOfResults := query.Page * query.PageSizeOr(Size)
 
-		pageQuery := 1
+		pageQuery := query.Page + 1
 
 		for {
 			hunterhowRequest := &Request{
-				Query:    query.Query,
-				PageSize: Size, // max size is 100
-				Page:     pageQuery,
+				Query:     query.Query,
+				PageSize:  query.PageSizeOr(Size), // max size is 100
+				Page:      pageQuery,
+				StartTime: query.Since,
+				EndTime:   query.Until,
 			}
 
 			if numberOfResults > query.Limit {
@@ -10,14 +10,23 @@ type Request struct {
 	Query    string `json:"query"`
 	Page     int    `json:"page"`
 	PageSize int    `json:"page_size"`
+	// EndTime defaults to today and StartTime to the earliest date hunter.how allows
+	// before EndTime
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
 }
 
 func (r *Request) buildURL(key string) string {
 	timeFormat := "2006-01-02"
-	now := time.Now()
-	firstDay := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
-	startTimeStr := firstDay.Format(timeFormat)
-	endTimeStr := now.Format(timeFormat)
+	startTime, endTime := r.StartTime, r.EndTime
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	if startTime.IsZero() {
+		startTime = earliestStart(endTime)
+	}
+	startTimeStr := startTime.Format(timeFormat)
+	endTimeStr := endTime.Format(timeFormat)
 
 	queryStr := baseURL +
 		baseEndpoint + "?api-key=" + key +
@@ -29,3 +38,9 @@ func (r *Request) buildURL(key string) string {
 
 	return queryStr
 }
+
+// earliestStart returns the earliest start time of a search ending at end, hunter.how
+// searches at most a year of results
+func earliestStart(end time.Time) time.Time {
+	return end.AddDate(-1, 0, 0)
+}
@@ -1,18 +1,17 @@
 package ip138_spider
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/wjlin0/uncover/sources"
-	"io"
 	"net/http"
-	"strings"
 )
 
 const (
 	URL    = "https://site.ip138.com/%s/domain.htm"
 	Source = "ip138-spider"
+	// InitURL sets the cookies asked again when a page is blocked
+	InitURL = "https://site.ip138.com/"
 )
 
 type Agent struct {
@@ -26,6 +25,11 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets ip138-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
@@ -39,34 +43,29 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	return results, nil
 }
 
-func (agent *Agent) queryURL(session *sources.Session, URL string, query *ip138Request) (*http.Response, error) {
-	ip138URL := fmt.Sprintf(URL, query.Domain)
-	request, err := sources.NewHTTPRequest(http.MethodGet, ip138URL, nil)
+func (agent *Agent) queryURL(session *sources.Session, URL string, cookies *sources.InitCookies) (*http.Response, error) {
+	request, err := sources.NewHTTPRequest(http.MethodGet, URL, nil)
 	if err != nil {
 		return nil, err
 	}
+	cookies.AddTo(request)
 	return session.Do(request, agent.Name())
 }
 
 func (agent *Agent) query(URL string, session *sources.Session, request *ip138Request, results chan sources.Result) (sub []string) {
-	var shouldIgnoreErrors bool
-	resp, err := agent.queryURL(session, URL, request)
+	ip138URL := fmt.Sprintf(URL, request.Domain)
+	cookies := &sources.InitCookies{Source: agent.Name(), InitURL: InitURL}
+	retry := &sources.BlockRetry{Source: agent.Name(), Bootstrap: func() error { return cookies.Bootstrap(session) }}
+	_, body, err := retry.Fetch(ip138URL, func() (*http.Response, error) {
+		return agent.queryURL(session, ip138URL, cookies)
+	})
+	if blocked, ok := sources.IsBlocked(err); ok {
+		blocked.Query = request.Domain
+	}
 	if err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
 		return
 	}
-	defer resp.Body.Close()
-	body := bytes.Buffer{}
-	_, err = io.Copy(&body, resp.Body)
-	if err != nil {
-		if strings.ContainsAny(err.Error(), "tls: user canceled") {
-			shouldIgnoreErrors = true
-		}
-		if !shouldIgnoreErrors {
-			results <- sources.Result{Source: agent.Name(), Error: err}
-			return
-		}
-	}
 	sub = sources.MatchSubdomains(request.Domain, body.String(), true)
 	for _, ip138 := range sub {
 		result := sources.Result{Source: agent.Name()}
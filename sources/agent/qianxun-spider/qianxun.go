@@ -29,6 +29,12 @@ type qianxunRequest struct {
 func (agent *Agent) Name() string {
 	return Source
 }
+
+// Capabilities are the kinds of targets qianxun-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
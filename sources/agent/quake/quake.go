@@ -10,12 +10,18 @@ import (
 	util "github.com/wjlin0/uncover/utils"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 const (
-	URL    = "https://quake.360.net/api/v3/search/quake_service"
-	Size   = 100
-	Source = "quake"
+	URL = "https://quake.360.net/api/v3/search/quake_service"
+	// AggregationURL counts the results of a query by field values
+	AggregationURL = "https://quake.360.net/api/v3/aggregation/quake_service"
+	Size           = 100
+	// MaxResults is the most results quake pages through for a query
+	MaxResults = 10000
+	Source     = "quake"
 )
 
 type Agent struct{}
@@ -24,6 +30,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets quake searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
+// Paging is how quake pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, ResultCost: 1, Unit: "points", MaxResults: MaxResults}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.QuakeToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -34,16 +50,13 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		numberOfResults := 0
+		numberOfResults := query.Page * query.PageSizeOr(Size)
 
 		for {
-			quakeRequest := &Request{
-				Query:       query.Query,
-				Size:        Size,
-				Start:       numberOfResults,
-				IgnoreCache: true,
-				Include:     []string{"ip", "port", "hostname", "domain"},
-			}
+			quakeRequest := newRequest(query)
+			quakeRequest.Size = query.PageSizeOr(Size)
+			quakeRequest.Start = numberOfResults
+			quakeRequest.Include = []string{"ip", "port", "hostname", "domain"}
 			quakeResponse := agent.query(URL, session, quakeRequest, results)
 			if quakeResponse == nil {
 				break
@@ -138,3 +151,107 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, quakeRequest
 	}
 	return resp, nil
 }
+
+// aggregationFields are the quake aggregation fields of the uncover facet fields
+var aggregationFields = map[string]string{
+	"port":    "port",
+	"country": "location.country_en",
+	"org":     "org",
+	"title":   "service.http.title",
+	"asn":     "asn",
+}
+
+func (agent *Agent) FacetFields() []string {
+	return []string{"port", "country", "org", "title", "asn"}
+}
+
+// Facets aggregates the results of query with the aggregation api
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, field string) ([]sources.Facet, error) {
+	if session.Keys.QuakeToken == "" {
+		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	name := aggregationFields[field]
+	body, err := json.Marshal(&AggregationRequest{Query: query.Query, AggregationList: []string{name}, Size: sources.DefaultFacetSize, StartTime: formatTime(query.Since), EndTime: formatTime(query.Until)})
+	if err != nil {
+		return nil, err
+	}
+	request, err := sources.NewHTTPRequest(http.MethodPost, AggregationURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-QuakeToken", session.Keys.QuakeToken)
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d received from %s", resp.StatusCode, AggregationURL)
+	}
+	aggregationResponse := &AggregationResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(aggregationResponse); err != nil {
+		return nil, err
+	}
+	if aggregationResponse.Code != 0 {
+		return nil, fmt.Errorf("quake aggregation failed: %s", aggregationResponse.Message)
+	}
+	var facets []sources.Facet
+	for _, bucket := range aggregationResponse.Data[name] {
+		facets = append(facets, sources.Facet{Field: field, Value: fmt.Sprint(bucket.Key), Count: bucket.DocCount})
+	}
+	return facets, nil
+}
+
+// Count returns the pagination total of query with a single result
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.QuakeToken == "" {
+		return 0, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	quakeRequest := newRequest(query)
+	quakeRequest.Size = 1
+	quakeRequest.Include = []string{"ip"}
+	resp, err := agent.queryURL(session, URL, quakeRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	quakeResponse := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(quakeResponse); err != nil {
+		return 0, errorutil.NewWithErr(err).Msgf("failed to decode quake response")
+	}
+	return quakeResponse.Meta.Pagination.Total, nil
+}
+
+// formatTime formats a bound of the time range as the utc times of quake, empty for no bound
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// newRequest returns the search request of query with its time range and options
+func newRequest(query *sources.Query) *Request {
+	request := &Request{
+		Query:       query.Query,
+		IgnoreCache: !strings.EqualFold(query.Options["ignore_cache"], "false"),
+		Latest:      strings.EqualFold(query.Options["latest"], "true"),
+		StartTime:   formatTime(query.Since),
+		EndTime:     formatTime(query.Until),
+	}
+	for _, shortcut := range strings.Split(query.Options["shortcuts"], ",") {
+		if shortcut = strings.TrimSpace(shortcut); shortcut != "" {
+			request.Shortcuts = append(request.Shortcuts, shortcut)
+		}
+	}
+	return request
+}
+
+func (agent *Agent) Options() []sources.Option {
+	return []sources.Option{
+		{Name: "latest", Description: "only return the latest data of each service", Values: sources.BoolValues},
+		{Name: "shortcuts", Description: "comma separated ids of quake search shortcuts, like excluding honeypots"},
+		{Name: "ignore_cache", Description: "bypass the cache of quake, true by default", Values: sources.BoolValues},
+	}
+}
@@ -6,4 +6,16 @@ type Request struct {
 	Start       int      `json:"start"`
 	IgnoreCache bool     `json:"ignore_cache"`
 	Include     []string `json:"include"`
+	StartTime   string   `json:"start_time,omitempty"`
+	EndTime     string   `json:"end_time,omitempty"`
+	Latest      bool     `json:"latest,omitempty"`
+	Shortcuts   []string `json:"shortcuts,omitempty"`
+}
+
+type AggregationRequest struct {
+	Query           string   `json:"query"`
+	AggregationList []string `json:"aggregation_list"`
+	Size            int      `json:"size"`
+	StartTime       string   `json:"start_time,omitempty"`
+	EndTime         string   `json:"end_time,omitempty"`
 }
@@ -23,3 +23,14 @@ type Response struct {
 	Message string         `json:"message"`
 	Meta    meta           `json:"meta"`
 }
+
+type aggregationBucket struct {
+	Key      interface{} `json:"key"`
+	DocCount int         `json:"doc_count"`
+}
+
+type AggregationResponse struct {
+	Code    int                            `json:"code"`
+	Message string                         `json:"message"`
+	Data    map[string][]aggregationBucket `json:"data"`
+}
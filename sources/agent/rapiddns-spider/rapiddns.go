@@ -1,19 +1,18 @@
 package rapiddns_spider
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/wjlin0/uncover/sources"
 	util "github.com/wjlin0/uncover/utils"
-	"io"
 	"net/http"
-	"strings"
 )
 
 const (
 	URL    = "https://rapiddns.io/subdomain/%s?full=1"
 	Source = "rapiddns-spider"
+	// InitURL sets the cookies asked again when a page is blocked
+	InitURL = "https://rapiddns.io/"
 )
 
 type Agent struct {
@@ -27,6 +26,11 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets rapiddns-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
@@ -40,34 +44,29 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	return results, nil
 }
 
-func (agent *Agent) queryURL(session *sources.Session, URL string, rapid *rapidDNS) (*http.Response, error) {
-	rapidURL := fmt.Sprintf(URL, rapid.Domain)
-	request, err := sources.NewHTTPRequest(http.MethodGet, rapidURL, nil)
+func (agent *Agent) queryURL(session *sources.Session, URL string, cookies *sources.InitCookies) (*http.Response, error) {
+	request, err := sources.NewHTTPRequest(http.MethodGet, URL, nil)
 	if err != nil {
 		return nil, err
 	}
+	cookies.AddTo(request)
 	return session.Do(request, agent.Name())
 }
 
 func (agent *Agent) query(URL string, session *sources.Session, rapid *rapidDNS, results chan sources.Result) (sub []string) {
-	var shouldIgnoreErrors bool
-	resp, err := agent.queryURL(session, URL, rapid)
+	rapidURL := fmt.Sprintf(URL, rapid.Domain)
+	cookies := &sources.InitCookies{Source: agent.Name(), InitURL: InitURL}
+	retry := &sources.BlockRetry{Source: agent.Name(), Bootstrap: func() error { return cookies.Bootstrap(session) }}
+	_, body, err := retry.Fetch(rapidURL, func() (*http.Response, error) {
+		return agent.queryURL(session, rapidURL, cookies)
+	})
+	if blocked, ok := sources.IsBlocked(err); ok {
+		blocked.Query = rapid.Domain
+	}
 	if err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
 		return
 	}
-	defer resp.Body.Close()
-	body := bytes.Buffer{}
-	_, err = io.Copy(&body, resp.Body)
-	if err != nil {
-		if strings.ContainsAny(err.Error(), "tls: user canceled") {
-			shouldIgnoreErrors = true
-		}
-		if !shouldIgnoreErrors {
-			results <- sources.Result{Source: agent.Name(), Error: err}
-			return
-		}
-	}
 	sub = sources.MatchSubdomains(rapid.Domain, body.String(), true)
 	for _, ra := range sub {
 		result := sources.Result{Source: agent.Name()}
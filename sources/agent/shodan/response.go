@@ -4,3 +4,13 @@ type ShodanResponse struct {
 	Total   int                      `json:"total"`
 	Results []map[string]interface{} `json:"matches"`
 }
+
+type ShodanCountResponse struct {
+	Total  int                            `json:"total"`
+	Facets map[string][]ShodanFacetBucket `json:"facets"`
+}
+
+type ShodanFacetBucket struct {
+	Count int         `json:"count"`
+	Value interface{} `json:"value"`
+}
@@ -12,7 +12,11 @@ import (
 )
 
 const (
-	URL    = "https://api.shodan.io/shodan/host/search?key=%s&query=%s&page=%d"
+	URL = "https://api.shodan.io/shodan/host/search?key=%s&query=%s&page=%d"
+	// CountURL returns the total and facets of a query without its results
+	CountURL = "https://api.shodan.io/shodan/host/count?key=%s&query=%s&facets=%s"
+	// Size is the number of results of a page
+	Size   = 100
 	Source = "shodan"
 )
 
@@ -22,6 +26,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets shodan searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
+// Paging is how shodan pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, PageCost: 1, Unit: "query credits"}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.Shodan == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -31,12 +45,12 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		currentPage := 1
-		var numberOfResults, totalResults int
+		currentPage := query.Page + 1
+		numberOfResults, totalResults := query.Page*Size, 0
 
 		for {
 			shodanRequest := &ShodanRequest{
-				Query: query.Query,
+				Query: timeQuery(query),
 				Page:  currentPage,
 			}
 
@@ -124,3 +138,84 @@ type ShodanRequest struct {
 	Query string
 	Page  int
 }
+
+// facetNames are the shodan facets of the uncover facet fields
+var facetNames = map[string]string{
+	"port":    "port",
+	"country": "country",
+	"product": "product",
+	"org":     "org",
+	"title":   "http.title",
+	"asn":     "asn",
+}
+
+func (agent *Agent) FacetFields() []string {
+	return []string{"port", "country", "product", "org", "title", "asn"}
+}
+
+// Facets aggregates the results of query with the count api which uses no query credit
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, field string) ([]sources.Facet, error) {
+	if session.Keys.Shodan == "" {
+		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	name := facetNames[field]
+	countURL := fmt.Sprintf(CountURL, session.Keys.Shodan, url.QueryEscape(timeQuery(query)), url.QueryEscape(fmt.Sprintf("%s:%d", name, sources.DefaultFacetSize)))
+	request, err := sources.NewHTTPRequest(http.MethodGet, countURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d received from %s", resp.StatusCode, CountURL)
+	}
+	countResponse := &ShodanCountResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(countResponse); err != nil {
+		return nil, err
+	}
+	var facets []sources.Facet
+	for _, bucket := range countResponse.Facets[name] {
+		facets = append(facets, sources.Facet{Field: field, Value: fmt.Sprint(bucket.Value), Count: bucket.Count})
+	}
+	return facets, nil
+}
+
+// Count returns the total of query with the count api which uses no query credit
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.Shodan == "" {
+		return 0, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	countURL := fmt.Sprintf(CountURL, session.Keys.Shodan, url.QueryEscape(timeQuery(query)), "")
+	request, err := sources.NewHTTPRequest(http.MethodGet, countURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code %d received from %s", resp.StatusCode, CountURL)
+	}
+	countResponse := &ShodanCountResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(countResponse); err != nil {
+		return 0, err
+	}
+	return countResponse.Total, nil
+}
+
+// timeQuery returns the query with the after and before filters of its time range
+func timeQuery(query *sources.Query) string {
+	q := query.Query
+	if !query.Since.IsZero() {
+		q += fmt.Sprintf(" after:%s", query.Since.Format("02/01/2006"))
+	}
+	if !query.Until.IsZero() {
+		q += fmt.Sprintf(" before:%s", query.Until.Format("02/01/2006"))
+	}
+	return q
+}
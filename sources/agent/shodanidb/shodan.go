@@ -22,6 +22,11 @@ func (agent *Agent) Name() string {
 	return "shodan-idb"
 }
 
+// Capabilities are the kinds of targets shodan-idb searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetIP, sources.TargetCIDR}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	results := make(chan sources.Result)
 
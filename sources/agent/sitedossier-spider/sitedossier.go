@@ -1,13 +1,11 @@
 package sitedossier_spider
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/wjlin0/uncover/sources"
 	util "github.com/wjlin0/uncover/utils"
-	"io"
 	"net/http"
 	"strings"
 )
@@ -15,8 +13,15 @@ import (
 const (
 	URL    = "http://www.sitedossier.com/parentdomain/%s/%d"
 	Source = "sitedossier-spider"
+	// InitURL sets the cookies asked again when a page is blocked
+	InitURL = "http://www.sitedossier.com/"
 )
 
+// BlockRules match the captcha page sitedossier redirects to after too many pages
+var BlockRules = []sources.BlockRule{
+	{Reason: "captcha", Location: "/captcha"},
+}
+
 type Agent struct {
 	options *sources.Agent
 }
@@ -28,6 +33,12 @@ type siteDossierRequest struct {
 func (agent *Agent) Name() string {
 	return Source
 }
+
+// Capabilities are the kinds of targets sitedossier-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
@@ -42,13 +53,14 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 		Results = make(map[string]struct{})
 		size = 1
+		cookies := &sources.InitCookies{Source: agent.Name(), InitURL: InitURL}
 		for {
 
 			request := &siteDossierRequest{
 				Domain: query.Query,
 				Size:   size,
 			}
-			response := agent.query(session, URL, request, Results, results)
+			response := agent.query(session, URL, request, cookies, Results, results)
 			if len(response) == 0 || numberOfResults > query.Limit {
 				break
 			}
@@ -64,26 +76,20 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	return results, nil
 }
-func (agent *Agent) query(session *sources.Session, URL string, request *siteDossierRequest, Results map[string]struct{}, results chan sources.Result) []string {
-	var (
-		shouldIgnoreErrors bool
-	)
-	resp, err := agent.queryURL(session, URL, request)
-	if err != nil {
-		results <- sources.Result{Source: agent.Name(), Error: errors.Wrap(err, "request error")}
+func (agent *Agent) query(session *sources.Session, URL string, request *siteDossierRequest, cookies *sources.InitCookies, Results map[string]struct{}, results chan sources.Result) []string {
+	requestURL := fmt.Sprintf(URL, request.Domain, request.Size)
+	retry := &sources.BlockRetry{Source: agent.Name(), Rules: BlockRules, Bootstrap: func() error { return cookies.Bootstrap(session) }}
+	_, body, err := retry.Fetch(requestURL, func() (*http.Response, error) {
+		return agent.queryURL(session, requestURL, cookies)
+	})
+	if blocked, ok := sources.IsBlocked(err); ok {
+		blocked.Query, blocked.Results = request.Domain, len(Results)
+		results <- sources.Result{Source: agent.Name(), Error: blocked}
 		return nil
 	}
-	defer resp.Body.Close()
-	body := bytes.Buffer{}
-	_, err = io.Copy(&body, resp.Body)
 	if err != nil {
-		if strings.ContainsAny(err.Error(), "tls: user canceled") {
-			shouldIgnoreErrors = true
-		}
-		if !shouldIgnoreErrors {
-			results <- sources.Result{Source: agent.Name(), Error: err}
-			return nil
-		}
+		results <- sources.Result{Source: agent.Name(), Error: errors.Wrap(err, "request error")}
+		return nil
 	}
 	sub := sources.MatchSubdomains(request.Domain, body.String(), false)
 
@@ -106,12 +112,11 @@ func (agent *Agent) query(session *sources.Session, URL string, request *siteDos
 	}
 	return sub
 }
-func (agent *Agent) queryURL(session *sources.Session, URL string, site *siteDossierRequest) (*http.Response, error) {
-
-	requestURL := fmt.Sprintf(URL, site.Domain, site.Size)
-	request, err := sources.NewHTTPRequest(http.MethodGet, requestURL, nil)
+func (agent *Agent) queryURL(session *sources.Session, URL string, cookies *sources.InitCookies) (*http.Response, error) {
+	request, err := sources.NewHTTPRequest(http.MethodGet, URL, nil)
 	if err != nil {
 		return nil, err
 	}
+	cookies.AddTo(request)
 	return session.Do(request, agent.Name())
 }
@@ -2,8 +2,11 @@ package yahoo_spider
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
+
 	"github.com/wjlin0/uncover/sources"
-	"net/http"
+	"github.com/wjlin0/uncover/sources/searchengine"
 )
 
 const (
@@ -11,58 +14,54 @@ const (
 	URLInit = "https://search.yahoo.com/"
 	Source  = "yahoo-spider"
 	Limit   = 500
+	PerPage = 50
+	// MaxQueryLength is the maximum length of a yahoo query
+	MaxQueryLength = 2000
 )
 
 type Agent struct {
 	options *sources.Agent
 }
 
-type yahooRequest struct {
-	Query   string `json:"p"`
-	Page    int    `json:"b"`
-	PerPage int    `json:"pz"`
-}
-
 func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets yahoo-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
-
-		cookies, err := agent.queryCookies(session)
-		if err != nil {
-			results <- sources.Result{Source: agent.Name(), Error: err}
-			return
-		}
-		newQuery(session, cookies, agent, query, results).run()
+		searchengine.Search(session, Engine(), query, results)
 	}()
 
 	return results, nil
 }
-func (agent *Agent) queryURL(session *sources.Session, URL string, cookies []*http.Cookie, yahooRequest *yahooRequest) (*http.Response, error) {
-	yahooURL := fmt.Sprintf(URL, yahooRequest.Query, yahooRequest.Page, yahooRequest.PerPage)
-	request, err := sources.NewHTTPRequest(http.MethodGet, yahooURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	for _, cookie := range cookies {
-		request.AddCookie(cookie)
-	}
-	return session.Do(request, agent.Name())
-}
 
-func (agent *Agent) queryCookies(session *sources.Session) ([]*http.Cookie, error) {
-	request, err := sources.NewHTTPRequest(http.MethodGet, URLInit, nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := session.Do(request, agent.Name())
-	if err != nil {
-		return nil, err
+// Engine returns the yahoo search engine
+func Engine() *searchengine.Engine {
+	return &searchengine.Engine{
+		Name:    Source,
+		InitURL: URLInit,
+		SearchURL: func(page searchengine.Page) string {
+			return fmt.Sprintf(URL, url.QueryEscape(page.Query), page.Offset, page.PerPage)
+		},
+		PerPage:        PerPage,
+		MaxOffset:      Limit,
+		MaxQueryLength: MaxQueryLength,
+		// matches are highlighted in bold inside of the urls
+		Parser: &searchengine.RegexParser{Unescape: true, Strip: []string{"<b>", "</b>"}},
+		HasNext: func(body string, page searchengine.Page) bool {
+			return strings.Contains(body, "Next<ins></ins></a>")
+		},
+		BlockRules: []sources.BlockRule{
+			{Reason: "consent", Location: "consent.yahoo.com"},
+			{Reason: "consent", Location: "guce.yahoo.com"},
+		},
 	}
-	return resp.Cookies(), nil
 }
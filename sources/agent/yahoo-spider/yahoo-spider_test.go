@@ -0,0 +1,28 @@
+package yahoo_spider
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources/searchengine"
+)
+
+func TestParse(t *testing.T) {
+	body, err := os.ReadFile("testdata/results.html")
+	require.Nil(t, err)
+	engine := Engine()
+
+	hosts := engine.Parser.Parse("example.com", body)
+	sort.Strings(hosts)
+	require.Equal(t, []string{"docs.example.com", "www.example.com"}, hosts)
+	require.True(t, engine.HasNext(string(body), searchengine.Page{Offset: PerPage}))
+
+	hosts = make([]string, 100)
+	for i := range hosts {
+		hosts[i] = strings.Repeat("a", 30) + ".example.com"
+	}
+	require.LessOrEqual(t, len(searchengine.Exclude("site:.example.com", hosts, engine.MaxQueryLength, engine.MaxQueryTerms)), MaxQueryLength)
+}
@@ -9,6 +9,7 @@ import (
 	util "github.com/wjlin0/uncover/utils"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/wjlin0/uncover/sources"
 )
@@ -34,6 +35,16 @@ func (agent *Agent) Name() string {
 	return Source
 }
 
+// Capabilities are the kinds of targets zone0 searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return []sources.TargetType{sources.TargetDomain, sources.TargetIP, sources.TargetRaw}
+}
+
+// Paging is how zone0 pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.Zone0Token == "" {
 		return nil, errors.New(fmt.Sprintf("empty %s keys please read docs %s on how to add keys ", Source, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -44,14 +55,14 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		var numberOfResults int
+		numberOfResults := query.Page * query.PageSizeOr(Size)
 
-		page := 1
+		page := query.Page + 1
 		for {
 			zone0Request := &request{
 				Query:     query.Query,
-				PageSize:  Size,
-				QueryType: Type,
+				PageSize:  query.PageSizeOr(Size),
+				QueryType: queryType(query),
 				Page:      page,
 			}
 			zone0Response := agent.query(URL, session, zone0Request, results)
@@ -128,3 +139,17 @@ func (agent *Agent) query(URL string, session *sources.Session, zone0Request *re
 	}
 	return zone0Response
 }
+
+// queryType returns the query_type option of query, site by default
+func queryType(query *sources.Query) string {
+	if queryType := strings.ToLower(query.Options["query_type"]); queryType != "" {
+		return queryType
+	}
+	return Type
+}
+
+func (agent *Agent) Options() []sources.Option {
+	return []sources.Option{
+		{Name: "query_type", Description: "kind of data searched, site by default", Values: []string{"site", "domain"}},
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/wjlin0/uncover/sources"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,6 +19,14 @@ const (
 	Source = "zoomeye-spider"
 )
 
+// BlockRules match the captcha and protection pages zoomeye shows instead of results
+var BlockRules = []sources.BlockRule{
+	{Reason: "rate limit", Body: `"status":429`},
+	{Reason: "rate limit", Body: `"status": 429`},
+	{Reason: "captcha", Body: "TencentCaptcha"},
+	{Reason: "interstitial", Body: "__jsl_clearance"},
+}
+
 type Agent struct{}
 
 type Request struct {
@@ -31,6 +38,12 @@ type Request struct {
 func (agent *Agent) Name() string {
 	return Source
 }
+
+// Capabilities are the kinds of targets zoomeye-spider searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 
 	results := make(chan sources.Result)
@@ -42,6 +55,10 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 		)
 
 		list, err := agent.queryAggsList(aggs, session, query)
+		if _, ok := sources.IsBlocked(err); ok {
+			results <- sources.Result{Source: agent.Name(), Error: err}
+			return
+		}
 		if err != nil {
 			results <- sources.Result{Source: agent.Name(), Error: errors.Wrap(err, "get fofa-spider error")}
 			return
@@ -68,6 +85,11 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 					q = url.QueryEscape(q)
 
 					spiderResult, err := agent.query(session, q, URL, query.Limit, results, &numberOfResults)
+					if blocked, ok := sources.IsBlocked(err); ok {
+						blocked.Query, blocked.Results = query.Query, numberOfResults+len(spiderResult)
+						results <- sources.Result{Source: agent.Name(), Error: blocked}
+						return
+					}
 					if err != nil {
 						results <- sources.Result{Source: agent.Name(), Error: errors.Wrap(err, "get zoomeye-spider error")}
 						return
@@ -91,9 +113,6 @@ func (agent *Agent) queryURL(session *sources.Session, zoomeyeRequest *Request,
 	if err != nil {
 		return nil, err
 	}
-	if cookies := os.Getenv("ZOOMEYE_COOKIE"); cookies != "" {
-		request.Header.Set("Cookie", cookies)
-	}
 	request.Header.Set("Referer", spiderURL)
 	return session.Do(request, agent.Name())
 }
@@ -106,16 +125,19 @@ func (agent *Agent) queryAggsList(URL string, session *sources.Session, query *s
 	if err != nil {
 		return nil, err
 	}
-	if cookies := os.Getenv("ZOOMEYE_COOKIE"); cookies != "" {
-		request.Header.Set("Cookie", cookies)
-	}
 	request.Header.Set("Referer", URL)
-	resp, err := session.Do(request, Source)
+	retry := &sources.BlockRetry{Source: Source, Rules: BlockRules}
+	_, body, err := retry.Fetch(URL, func() (*http.Response, error) {
+		return session.Do(request, Source)
+	})
+	if blocked, ok := sources.IsBlocked(err); ok {
+		blocked.Query = query.Query
+		return nil, blocked
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if err = json.NewDecoder(resp.Body).Decode(aggsRes); err != nil {
+	if err = json.NewDecoder(body).Decode(aggsRes); err != nil {
 		return nil, err
 	}
 	if aggsRes.Status != 200 {
@@ -165,10 +187,13 @@ func (agent *Agent) queryAggsList(URL string, session *sources.Session, query *s
 	return temp, nil
 }
 
+// query sends the results of the city query page by page, a BlockedError is returned
+// when zoomeye keeps blocking its pages
 func (agent *Agent) query(session *sources.Session, q string, url string, limit int, results chan sources.Result, num *int) ([]sources.Result, error) {
 	var (
 		spiderResult []sources.Result
 	)
+	retry := &sources.BlockRetry{Source: Source, Rules: BlockRules}
 	page := 1
 	for {
 		zoomeye := &Request{
@@ -180,22 +205,24 @@ func (agent *Agent) query(session *sources.Session, q string, url string, limit
 			break
 		}
 
-		resp, err := agent.queryURL(session, zoomeye, url)
-		if err != nil {
-			continue
+		spiderURL := fmt.Sprintf(url, zoomeye.Query, zoomeye.Page, zoomeye.PageSize)
+		resp, body, err := retry.Fetch(spiderURL, func() (*http.Response, error) {
+			return agent.queryURL(session, zoomeye, url)
+		})
+		if _, ok := sources.IsBlocked(err); ok {
+			return spiderResult, err
 		}
-		body, err := sources.ReadBody(resp)
 		if err != nil || body == nil {
 			continue
 		}
+		if err := session.LoggedOut(Source, resp, body.String()); err != nil {
+			results <- sources.Result{Source: Source, Error: err}
+		}
 		responseJson := &response{}
 		// json 序列化
 		if err = json.NewDecoder(body).Decode(responseJson); err != nil {
 			continue
 		}
-		if responseJson.Status == 429 {
-			return spiderResult, fmt.Errorf("zoomeye api rate limit")
-		}
 		if responseJson.Status != 200 {
 			break
 		}
@@ -280,3 +307,20 @@ func (agent *Agent) query(session *sources.Session, q string, url string, limit
 	return spiderResult, nil
 
 }
+
+func (agent *Agent) FacetFields() []string {
+	return []string{"country"}
+}
+
+// Facets counts the results of query by country with the aggs list used to split queries
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, field string) ([]sources.Facet, error) {
+	list, err := agent.queryAggsList(aggs, session, query)
+	if err != nil {
+		return nil, err
+	}
+	var facets []sources.Facet
+	for _, c := range list.Country {
+		facets = append(facets, sources.Facet{Field: field, Value: c.Name, Count: c.Count})
+	}
+	return facets, nil
+}
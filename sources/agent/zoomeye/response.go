@@ -4,3 +4,13 @@ type ZoomEyeResponse struct {
 	Total   int                      `json:"total"`
 	Results []map[string]interface{} `json:"matches"`
 }
+
+type ZoomEyeFacetResponse struct {
+	Total  int                             `json:"total"`
+	Facets map[string][]ZoomEyeFacetBucket `json:"facets"`
+}
+
+type ZoomEyeFacetBucket struct {
+	Name  interface{} `json:"name"`
+	Count int         `json:"count"`
+}
@@ -13,6 +13,13 @@ import (
 
 const (
 	URL = "https://api.zoomeye.org/web/search?query=%s&page=%d"
+	// FacetURL counts the web results of a query by the values of a facet, from the same
+	// index as URL
+	FacetURL = "https://api.zoomeye.org/web/search?query=%s&page=1&facets=%s"
+	// Size is the number of results of a page
+	Size = 20
+	// MaxResults is the most results zoomeye pages through for a query
+	MaxResults = 10000
 )
 
 type Agent struct{}
@@ -21,6 +28,16 @@ func (agent *Agent) Name() string {
 	return "zoomeye"
 }
 
+// Capabilities are the kinds of targets zoomeye searches
+func (agent *Agent) Capabilities() []sources.TargetType {
+	return sources.AllTargets
+}
+
+// Paging is how zoomeye pages the results of queries
+func (agent *Agent) Paging() sources.Paging {
+	return sources.Paging{PageSize: Size, ResultCost: 1, Unit: "credits", MaxResults: MaxResults}
+}
+
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.ZoomEyeToken == "" {
 		return nil, errors.New(fmt.Sprintf("empty zoomeye keys please read docs %s on how to add keys ", "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
@@ -30,12 +47,12 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	go func() {
 		defer close(results)
 
-		currentPage := 1
-		var numberOfResults, totalResults int
+		currentPage := query.Page + 1
+		numberOfResults, totalResults := query.Page*Size, 0
 
 		for {
 			zoomeyeRequest := &ZoomEyeRequest{
-				Query: query.Query,
+				Query: timeQuery(query),
 				Page:  currentPage,
 			}
 
@@ -158,3 +175,74 @@ func convertPortFromValue(value interface{}) int {
 		return 0
 	}
 }
+
+// facetNames are the zoomeye web search facets of the uncover facet fields, web results
+// have no port facet
+var facetNames = map[string]string{
+	"country": "country",
+	"product": "webapp",
+}
+
+func (agent *Agent) FacetFields() []string {
+	return []string{"country", "product"}
+}
+
+// Facets aggregates the results of query with the facets of the web search
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, field string) ([]sources.Facet, error) {
+	if session.Keys.ZoomEyeToken == "" {
+		return nil, errors.New(fmt.Sprintf("empty zoomeye keys please read docs %s on how to add keys ", "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	name := facetNames[field]
+	facetURL := fmt.Sprintf(FacetURL, url.QueryEscape(timeQuery(query)), name)
+	request, err := sources.NewHTTPRequest(http.MethodGet, facetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("API-KEY", session.Keys.ZoomEyeToken)
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d received from %s", resp.StatusCode, facetURL)
+	}
+	facetResponse := &ZoomEyeFacetResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(facetResponse); err != nil {
+		return nil, err
+	}
+	var facets []sources.Facet
+	for _, bucket := range facetResponse.Facets[name] {
+		facets = append(facets, sources.Facet{Field: field, Value: fmt.Sprint(bucket.Name), Count: bucket.Count})
+	}
+	return facets, nil
+}
+
+// Count returns the total of query from its first page
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.ZoomEyeToken == "" {
+		return 0, errors.New(fmt.Sprintf("empty zoomeye keys please read docs %s on how to add keys ", "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration"))
+	}
+	resp, err := agent.queryURL(session, URL, &ZoomEyeRequest{Query: timeQuery(query), Page: 1})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	zoomeyeResponse := &ZoomEyeResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(zoomeyeResponse); err != nil {
+		return 0, err
+	}
+	return zoomeyeResponse.Total, nil
+}
+
+// timeQuery returns the query with the after and before filters of its time range
+func timeQuery(query *sources.Query) string {
+	q := query.Query
+	if !query.Since.IsZero() {
+		q += fmt.Sprintf(` +after:"%s"`, query.Since.Format("2006-01-02"))
+	}
+	if !query.Until.IsZero() {
+		q += fmt.Sprintf(` +before:"%s"`, query.Until.Format("2006-01-02"))
+	}
+	return q
+}
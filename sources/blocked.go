@@ -0,0 +1,188 @@
+package sources
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+var (
+	// DefaultBlockRetries is the number of times a blocked page is requested again
+	DefaultBlockRetries = 2
+	// DefaultBlockBackoff is the wait before the first retry of a blocked page, doubled on each retry
+	DefaultBlockBackoff = 5 * time.Second
+)
+
+// BlockRule matches a page shown instead of results, like a captcha or a consent page.
+// a rule matches when all of its non empty fields match
+type BlockRule struct {
+	// Reason is the kind of page, like captcha, consent, interstitial or rate limit
+	Reason string
+	Status int
+	// Location is a part of the redirect of the response
+	Location string
+	// Body is a part of the page, matched case insensitively
+	Body string
+}
+
+// DefaultBlockRules are the pages shown by most engines and the protections in front of them
+var DefaultBlockRules = []BlockRule{
+	{Reason: "rate limit", Status: http.StatusTooManyRequests},
+	{Reason: "captcha", Body: "g-recaptcha"},
+	{Reason: "captcha", Body: "h-captcha"},
+	{Reason: "captcha", Body: "unusual traffic from your computer network"},
+	{Reason: "interstitial", Body: "challenge-platform"},
+	{Reason: "interstitial", Body: "<title>Just a moment...</title>"},
+}
+
+// DetectBlock returns the reason of the first rule matching a response and its body,
+// false is returned when the page is not a block page
+func DetectBlock(resp *http.Response, body string, rules ...BlockRule) (string, bool) {
+	return matchRules(resp, body, append(rules, DefaultBlockRules...))
+}
+
+// matchRules returns the reason of the first of rules matching a response and its body
+func matchRules(resp *http.Response, body string, rules []BlockRule) (string, bool) {
+	location := resp.Header.Get("Location")
+	lowerBody := strings.ToLower(body)
+	for _, rule := range rules {
+		if rule.Status == 0 && rule.Location == "" && rule.Body == "" {
+			continue
+		}
+		if rule.Status != 0 && rule.Status != resp.StatusCode {
+			continue
+		}
+		if rule.Location != "" && !strings.Contains(location, rule.Location) {
+			continue
+		}
+		if rule.Body != "" && !strings.Contains(lowerBody, strings.ToLower(rule.Body)) {
+			continue
+		}
+		return rule.Reason, true
+	}
+	return "", false
+}
+
+// BlockRetry requests the pages of a source again while they are blocked
+type BlockRetry struct {
+	Source string
+	// Rules match the block pages of the source, along with DefaultBlockRules
+	Rules []BlockRule
+	// Bootstrap gets new cookies of the source before each retry, skipped when nil
+	Bootstrap func() error
+	// Retries and Backoff are DefaultBlockRetries and DefaultBlockBackoff when 0
+	Retries int
+	Backoff time.Duration
+}
+
+// Fetch returns the response of do for URL with its body, a blocked page is requested
+// again after a backoff and a Bootstrap until the retries run out. a BlockedError with
+// the Source, Reason and URL of the block is returned then
+func (b *BlockRetry) Fetch(URL string, do func() (*http.Response, error)) (*http.Response, *bytes.Buffer, error) {
+	retries, backoff := b.Retries, b.Backoff
+	if retries <= 0 {
+		retries = DefaultBlockRetries
+	}
+	if backoff <= 0 {
+		backoff = DefaultBlockBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := do()
+		if err != nil {
+			return nil, nil, err
+		}
+		body, err := ReadBody(resp)
+		if err != nil {
+			return nil, nil, err
+		}
+		reason, blocked := DetectBlock(resp, body.String(), b.Rules...)
+		if !blocked {
+			return resp, body, nil
+		}
+		if attempt == retries {
+			return resp, body, &BlockedError{Source: b.Source, Reason: reason, URL: URL}
+		}
+		gologger.Verbose().Label(b.Source).Msgf("Blocked by a %s page, retrying in %s\n", reason, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if b.Bootstrap == nil {
+			continue
+		}
+		if err := b.Bootstrap(); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// InitCookies are the cookies a source gets from its InitURL, like the ones of the
+// protection in front of a site
+type InitCookies struct {
+	Source  string
+	InitURL string
+	mu      sync.Mutex
+	cookies []*http.Cookie
+}
+
+// Bootstrap replaces the cookies with the ones set by InitURL
+func (c *InitCookies) Bootstrap(session *Session) error {
+	request, err := NewHTTPRequest(http.MethodGet, c.InitURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := session.Do(request, c.Source)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not get %s cookies", c.Source)
+	}
+	resp.Body.Close()
+	c.mu.Lock()
+	c.cookies = resp.Cookies()
+	c.mu.Unlock()
+	return nil
+}
+
+// AddTo adds the cookies to request
+func (c *InitCookies) AddTo(request *retryablehttp.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cookie := range c.cookies {
+		request.AddCookie(cookie)
+	}
+}
+
+// BlockedError is returned by agents shown a block page instead of results, with how
+// much of the query was searched before
+type BlockedError struct {
+	Source string
+	Query  string
+	Reason string
+	URL    string
+	// Results are the results found before the block
+	Results int
+	// Searches are the searches attempted, the blocked one included, 0 when unknown
+	Searches int
+}
+
+func (e *BlockedError) Error() string {
+	msg := fmt.Sprintf("blocked by a %s page at %s after %d results", e.Reason, e.URL, e.Results)
+	if e.Searches > 0 {
+		msg += fmt.Sprintf(" on search %d", e.Searches)
+	}
+	return msg
+}
+
+// IsBlocked returns the BlockedError of err, false is returned when err is not one
+func IsBlocked(err error) (*BlockedError, bool) {
+	var blocked *BlockedError
+	if errors.As(err, &blocked) {
+		return blocked, true
+	}
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package sources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectBlock(t *testing.T) {
+	response := func(status int, location string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		if location != "" {
+			resp.Header.Set("Location", location)
+		}
+		return resp
+	}
+	sorry := BlockRule{Reason: "captcha", Location: "/sorry/"}
+
+	reason, ok := DetectBlock(response(http.StatusFound, "https://www.google.com/sorry/index"), "302 Moved", sorry)
+	require.True(t, ok)
+	require.Equal(t, "captcha", reason)
+
+	reason, ok = DetectBlock(response(http.StatusTooManyRequests, ""), "")
+	require.True(t, ok)
+	require.Equal(t, "rate limit", reason)
+
+	reason, ok = DetectBlock(response(http.StatusOK, ""), "<html><TITLE>Just a moment...</TITLE></html>")
+	require.True(t, ok)
+	require.Equal(t, "interstitial", reason)
+
+	_, ok = DetectBlock(response(http.StatusOK, ""), "<a href=\"https://www.example.com\">example</a>", sorry)
+	require.False(t, ok)
+
+	err := fmt.Errorf("query failed: %w", &BlockedError{Source: "google-spider", Reason: "captcha", URL: "https://www.google.com/search", Results: 37, Searches: 3})
+	blocked, ok := IsBlocked(err)
+	require.True(t, ok)
+	require.Equal(t, "blocked by a captcha page at https://www.google.com/search after 37 results on search 3", blocked.Error())
+	_, ok = IsBlocked(fmt.Errorf("timeout"))
+	require.False(t, ok)
+}
+
+func TestBlockRetry(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.SetCookie(w, &http.Cookie{Name: "clearance", Value: "ok"})
+			return
+		}
+		requests++
+		if _, err := r.Cookie("clearance"); err != nil || r.URL.Path == "/always" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("www.example.com"))
+	}))
+	defer ts.Close()
+	session, err := NewSession(&Keys{}, 0, 5, 60, []string{"test"}, time.Second, "", "")
+	require.Nil(t, err)
+
+	cookies := &InitCookies{Source: "test", InitURL: ts.URL + "/"}
+	retry := &BlockRetry{Source: "test", Bootstrap: func() error { return cookies.Bootstrap(session) }, Retries: 2, Backoff: time.Millisecond}
+	fetch := func(URL string) (*http.Response, error) {
+		request, err := NewHTTPRequest(http.MethodGet, URL, nil)
+		require.Nil(t, err)
+		cookies.AddTo(request)
+		return session.Do(request, "test")
+	}
+
+	// the first request is blocked until the cookies are bootstrapped
+	_, body, err := retry.Fetch(ts.URL+"/search", func() (*http.Response, error) { return fetch(ts.URL + "/search") })
+	require.Nil(t, err)
+	require.Equal(t, "www.example.com", body.String())
+	require.Equal(t, 2, requests)
+
+	requests = 0
+	_, _, err = retry.Fetch(ts.URL+"/always", func() (*http.Response, error) { return fetch(ts.URL + "/always") })
+	blocked, ok := IsBlocked(err)
+	require.True(t, ok)
+	require.Equal(t, "rate limit", blocked.Reason)
+	require.Equal(t, ts.URL+"/always", blocked.URL)
+	require.Equal(t, 3, requests)
+}
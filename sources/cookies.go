@@ -0,0 +1,305 @@
+package sources
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// CookieAuth is how the requests of a spider agent are authenticated with the session
+// of a web account
+type CookieAuth struct {
+	// URL is the site the imported cookies without domain are set on
+	URL string
+	// TokenCookie or TokenHeader receive a token imported instead of cookies, like a JWT
+	TokenCookie string
+	TokenHeader string
+	// LoggedOut match the responses of the site to requests without a valid session
+	LoggedOut []BlockRule
+}
+
+// DefaultCookieAuth of the spider agents able to use the session of a web account
+var DefaultCookieAuth = map[string]CookieAuth{
+	"fofa-spider": {
+		URL:         "https://fofa.info/",
+		TokenCookie: "fofa_token",
+		LoggedOut: []BlockRule{
+			{Reason: "login", Location: "/login"},
+			{Reason: "login", Status: http.StatusUnauthorized},
+		},
+	},
+	"zoomeye-spider": {
+		URL:         "https://www.zoomeye.org/",
+		TokenHeader: "Cube-Authorization",
+		LoggedOut: []BlockRule{
+			{Reason: "login", Status: http.StatusUnauthorized},
+			{Reason: "login", Body: `"status":401`},
+			{Reason: "login", Body: `"status": 401`},
+		},
+	},
+}
+
+// CookieAgents returns the agents of DefaultCookieAuth
+func CookieAgents() []string {
+	agents := make([]string, 0, len(DefaultCookieAuth))
+	for agent := range DefaultCookieAuth {
+		agents = append(agents, agent)
+	}
+	sort.Strings(agents)
+	return agents
+}
+
+// Credentials are the cookies or the token of a web account imported for an agent
+type Credentials struct {
+	Cookies []*http.Cookie
+	Token   string
+}
+
+var tokenReg = regexp.MustCompile(`^[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*$`)
+
+// ReadCredentials reads the credentials of a cookie file, value itself is parsed when it
+// is not a file
+func ReadCredentials(value string) (*Credentials, error) {
+	if fileutil.FileExists(value) {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("could not read cookie file %s", value)
+		}
+		value = string(data)
+	}
+	return ParseCredentials(value)
+}
+
+// ParseCredentials parses a Netscape cookies.txt, a JSON cookie export (an array of
+// browser cookies or an object of names and values), a Cookie header or a bare token
+func ParseCredentials(data string) (*Credentials, error) {
+	data = strings.TrimSpace(data)
+	switch {
+	case data == "":
+		return nil, errorutil.NewWithTag("uncover", "no cookie given")
+	case strings.HasPrefix(data, "["):
+		return parseJSONCookies(data)
+	case strings.HasPrefix(data, "{"):
+		return parseJSONObject(data)
+	case strings.HasPrefix(data, "# Netscape") || strings.Contains(data, "\t"):
+		return parseNetscapeCookies(data)
+	case tokenReg.MatchString(data) || !strings.Contains(data, "="):
+		return &Credentials{Token: data}, nil
+	}
+	request := &http.Request{Header: http.Header{"Cookie": {data}}}
+	cookies := request.Cookies()
+	if len(cookies) == 0 {
+		return nil, errorutil.NewWithTag("uncover", "invalid cookie header")
+	}
+	return &Credentials{Cookies: cookies}, nil
+}
+
+// jsonCookie is a cookie of the JSON exports of browser extensions
+type jsonCookie struct {
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Domain         string  `json:"domain"`
+	Path           string  `json:"path"`
+	Secure         bool    `json:"secure"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	ExpirationDate float64 `json:"expirationDate"`
+	Expires        float64 `json:"expires"`
+}
+
+func parseJSONCookies(data string) (*Credentials, error) {
+	var exported []jsonCookie
+	if err := json.Unmarshal([]byte(data), &exported); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid JSON cookies")
+	}
+	credentials := &Credentials{}
+	for _, c := range exported {
+		if c.Name == "" {
+			continue
+		}
+		cookie := &http.Cookie{Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path, Secure: c.Secure, HttpOnly: c.HTTPOnly}
+		if expires := c.ExpirationDate + c.Expires; expires > 0 {
+			cookie.Expires = time.Unix(int64(expires), 0)
+		}
+		credentials.Cookies = append(credentials.Cookies, cookie)
+	}
+	return credentials, nil
+}
+
+// parseJSONObject parses {"name": "value"} cookies, a token or jwt key is a token
+func parseJSONObject(data string) (*Credentials, error) {
+	var values map[string]string
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid JSON cookies")
+	}
+	credentials := &Credentials{}
+	for name, value := range values {
+		switch strings.ToLower(name) {
+		case "token", "jwt":
+			credentials.Token = value
+		default:
+			credentials.Cookies = append(credentials.Cookies, &http.Cookie{Name: name, Value: value})
+		}
+	}
+	return credentials, nil
+}
+
+func parseNetscapeCookies(data string) (*Credentials, error) {
+	credentials := &Credentials{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// domain, include subdomains, path, secure, expires, name, value
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, errorutil.NewWithTag("uncover", "invalid cookies.txt line %s", line)
+		}
+		cookie := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		credentials.Cookies = append(credentials.Cookies, cookie)
+	}
+	return credentials, nil
+}
+
+// ParseCookieFiles parses agent=file values into the cookie file of each agent
+func ParseCookieFiles(values []string) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, value := range values {
+		agent, file, ok := strings.Cut(value, "=")
+		agent, file = strings.ToLower(strings.TrimSpace(agent)), strings.TrimSpace(file)
+		if !ok || agent == "" || file == "" {
+			return nil, errorutil.NewWithTag("uncover", "invalid cookie file %s, use agent=file (example: -cookie-file fofa-spider=cookies.txt)", value)
+		}
+		files[agent] = file
+	}
+	return files, nil
+}
+
+// accountJar holds the cookies of the web account of an agent, refreshed by the
+// cookies set in its responses
+type accountJar struct {
+	auth      CookieAuth
+	jar       *cookiejar.Jar
+	token     string
+	mu        sync.Mutex
+	loggedOut bool
+}
+
+func newAccountJar(auth CookieAuth, credentials *Credentials) (*accountJar, error) {
+	site, err := url.Parse(auth.URL)
+	if err != nil {
+		return nil, err
+	}
+	jar, _ := cookiejar.New(nil)
+	account := &accountJar{auth: auth, jar: jar, token: credentials.Token}
+	cookies := credentials.Cookies
+	if account.token != "" && auth.TokenCookie != "" {
+		cookies = append(cookies, &http.Cookie{Name: auth.TokenCookie, Value: account.token})
+	}
+	for _, cookie := range cookies {
+		// cookies without domain are shared with the subdomains of the site, like its api
+		if cookie.Domain == "" {
+			cookie.Domain = site.Hostname()
+		}
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+		jar.SetCookies(&url.URL{Scheme: site.Scheme, Host: strings.TrimPrefix(cookie.Domain, "."), Path: cookie.Path}, []*http.Cookie{cookie})
+	}
+	return account, nil
+}
+
+// SetCredentials sends the cookies or token of a web account with the requests of agent
+func (s *Session) SetCredentials(agent string, credentials *Credentials) error {
+	auth, ok := DefaultCookieAuth[agent]
+	if !ok {
+		return errorutil.NewWithTag("uncover", "%s agent doesn't support cookies", agent)
+	}
+	if credentials.Token != "" && auth.TokenCookie == "" && auth.TokenHeader == "" {
+		return errorutil.NewWithTag("uncover", "%s agent doesn't support tokens, export its cookies", agent)
+	}
+	account, err := newAccountJar(auth, credentials)
+	if err != nil {
+		return err
+	}
+	if s.accounts == nil {
+		s.accounts = make(map[string]*accountJar)
+	}
+	s.accounts[agent] = account
+	return nil
+}
+
+// HasCredentials returns true when a web account is used for the requests of agent
+func (s *Session) HasCredentials(agent string) bool {
+	_, ok := s.accounts[agent]
+	return ok
+}
+
+// LoggedOut returns an error the first time a response of agent shows its web account
+// is logged out, the results are then the ones of anonymous users
+func (s *Session) LoggedOut(agent string, resp *http.Response, body string) error {
+	account, ok := s.accounts[agent]
+	if !ok {
+		return nil
+	}
+	if _, loggedOut := matchRules(resp, body, account.auth.LoggedOut); !loggedOut {
+		return nil
+	}
+	account.mu.Lock()
+	defer account.mu.Unlock()
+	if account.loggedOut {
+		return nil
+	}
+	account.loggedOut = true
+	return errorutil.NewWithTag("uncover", "%s account is logged out, results are limited to anonymous ones, import fresh cookies", agent)
+}
+
+// addCredentials adds the cookies and token of the account of agent to request
+func (s *Session) addCredentials(agent string, request *http.Request) {
+	account, ok := s.accounts[agent]
+	if !ok {
+		return
+	}
+	for _, cookie := range account.jar.Cookies(request.URL) {
+		request.AddCookie(cookie)
+	}
+	if account.token != "" && account.auth.TokenHeader != "" {
+		request.Header.Set(account.auth.TokenHeader, account.token)
+	}
+}
+
+// refreshCredentials keeps the cookies set by a response to a request of agent
+func (s *Session) refreshCredentials(agent string, resp *http.Response) {
+	account, ok := s.accounts[agent]
+	if !ok || resp.Request == nil {
+		return
+	}
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		account.jar.SetCookies(resp.Request.URL, cookies)
+	}
+}
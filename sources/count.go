@@ -0,0 +1,73 @@
+package sources
+
+// Counter is implemented by agents able to return the total number of results of a
+// query without fetching them
+type Counter interface {
+	Count(session *Session, query *Query) (int, error)
+}
+
+// Total is the number of results of a query on an engine
+type Total struct {
+	Source string `json:"source"`
+	Query  string `json:"query"`
+	Total  int    `json:"total"`
+}
+
+// Paging is how an engine pages its results and what a page costs
+type Paging struct {
+	PageSize int
+	// LargePageSize is used instead of PageSize when the limit is over five pages
+	LargePageSize int
+	// PageCost and ResultCost are the credits of each fetched page and result
+	PageCost   int
+	ResultCost int
+	// Unit is the name of the credits of the engine
+	Unit string
+	// MaxResults is the most results the engine pages through for a query, larger
+	// limits are reached by splitting queries into shards
+	MaxResults int
+}
+
+// Pager is implemented by agents fetching results by pages, their paging plans queries
+// and splits the ones over MaxResults
+type Pager interface {
+	Paging() Paging
+}
+
+// PagingOf returns the paging of agent, false is returned when its paging is unknown
+func PagingOf(agent Agent) (Paging, bool) {
+	pager, ok := agent.(Pager)
+	if !ok {
+		return Paging{}, false
+	}
+	return pager.Paging(), true
+}
+
+// Plan is what fetching the results of a query on an engine would cost
+type Plan struct {
+	Source   string `json:"source"`
+	Query    string `json:"query"`
+	Limit    int    `json:"limit"`
+	PageSize int    `json:"page_size,omitempty"`
+	Pages    int    `json:"pages,omitempty"`
+	Credits  int    `json:"credits,omitempty"`
+	Unit     string `json:"unit,omitempty"`
+}
+
+// NewPlan returns the pages of query agent fetches under the limit of query and their
+// estimated cost, the pages are 0 when the paging of agent is unknown
+func NewPlan(agent Agent, query *Query) Plan {
+	plan := Plan{Source: agent.Name(), Query: query.Query, Limit: query.Limit}
+	paging, ok := PagingOf(agent)
+	if !ok || query.Limit <= 0 {
+		return plan
+	}
+	plan.PageSize = query.PageSizeOr(paging.PageSize)
+	if query.PageSize == 0 && paging.LargePageSize > 0 && query.Limit > paging.PageSize*5 {
+		plan.PageSize = paging.LargePageSize
+	}
+	plan.Pages = (query.Limit + plan.PageSize - 1) / plan.PageSize
+	plan.Credits = plan.Pages*paging.PageCost + plan.Pages*plan.PageSize*paging.ResultCost
+	plan.Unit = paging.Unit
+	return plan
+}
@@ -0,0 +1,33 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pagerAgent pages results like fofa
+type pagerAgent struct {
+	fakeAgent
+}
+
+func (agent *pagerAgent) Paging() Paging {
+	return Paging{PageSize: 100, LargePageSize: 500, ResultCost: 1, Unit: "results quota", MaxResults: 10000}
+}
+
+func TestNewPlan(t *testing.T) {
+	plan := NewPlan(&pagerAgent{}, &Query{Query: `domain="example.com"`, Limit: 250})
+	require.Equal(t, 3, plan.Pages)
+	require.Equal(t, 300, plan.Credits)
+
+	plan = NewPlan(&pagerAgent{}, &Query{Query: `domain="example.com"`, Limit: 1200})
+	require.Equal(t, 500, plan.PageSize)
+	require.Equal(t, 3, plan.Pages)
+
+	plan = NewPlan(&pagerAgent{}, &Query{Query: `domain="example.com"`, Limit: 1200, PageSize: 50})
+	require.Equal(t, 50, plan.PageSize)
+	require.Equal(t, 24, plan.Pages)
+
+	plan = NewPlan(&fakeAgent{}, &Query{Query: "example.com", Limit: 100})
+	require.Zero(t, plan.Pages, "paging of agents which are not pagers is unknown")
+}
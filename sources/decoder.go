@@ -0,0 +1,227 @@
+package sources
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ResultDecoder reads the results saved by uncover as JSONL or CSV, the JSON lines
+// of other tools (httpx, naabu, ...) and lines of urls, host:port, hosts and ips
+type ResultDecoder struct {
+	scanner *bufio.Scanner
+	// header maps the lowercased csv column names to their index once a csv header was read
+	header map[string]int
+	line   int
+	// failed is set once the read error of the scanner was returned
+	failed bool
+}
+
+// NewResultDecoder creates a decoder reading the results of reader
+func NewResultDecoder(reader io.Reader) *ResultDecoder {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &ResultDecoder{scanner: scanner}
+}
+
+// Decode returns the next result, io.EOF is returned at the end of input
+func (d *ResultDecoder) Decode() (Result, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			result, err := decodeJSONResult([]byte(line))
+			if err != nil {
+				return result, errors.Wrapf(err, "invalid json result on line %d", d.line)
+			}
+			return result, nil
+		}
+		if d.header == nil && strings.Contains(line, ",") {
+			if header := csvHeader(line); header != nil {
+				d.header = header
+				continue
+			}
+		}
+		if d.header != nil {
+			result, err := d.decodeCSV(line)
+			if err != nil {
+				return result, errors.Wrapf(err, "invalid csv result on line %d", d.line)
+			}
+			return result, nil
+		}
+		return decodeLine(line), nil
+	}
+	if err := d.scanner.Err(); err != nil && !d.failed {
+		d.failed = true
+		return Result{}, err
+	}
+	return Result{}, io.EOF
+}
+
+// jsonAliases are the fields of other tools holding the fields of a result
+var jsonAliases = map[string][]string{
+	"ip":        {"ip", "host_ip", "a"},
+	"host":      {"hostname", "domain", "input", "host"},
+	"port":      {"port"},
+	"url":       {"url"},
+	"source":    {"source"},
+	"query":     {"query"},
+	"timestamp": {"timestamp"},
+}
+
+// decodeJSONResult decodes a result written by uncover, the fields it leaves empty
+// are filled from the common field names of other tools
+func decodeJSONResult(data []byte) (Result, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Result{}, err
+	}
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		// the fields of other tools with the names of uncover ones may mean something else
+		result = Result{}
+	}
+	value := func(name string) string {
+		for _, alias := range jsonAliases[name] {
+			switch v := fields[alias].(type) {
+			case string:
+				if v != "" {
+					return v
+				}
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			case []interface{}:
+				// dns answers, the first one is used
+				if len(v) > 0 {
+					if first, ok := v[0].(string); ok {
+						return first
+					}
+				}
+			}
+		}
+		return ""
+	}
+	fill := func(field *string, name string) {
+		if *field == "" {
+			*field = value(name)
+		}
+	}
+	fill(&result.Source, "source")
+	fill(&result.IP, "ip")
+	fill(&result.Host, "host")
+	fill(&result.Url, "url")
+	fill(&result.Query, "query")
+	if result.Timestamp == 0 {
+		result.Timestamp = parseTimestamp(value("timestamp"))
+	}
+	if result.Port == 0 {
+		result.Port, _ = strconv.Atoi(value("port"))
+	}
+	result.Raw = data
+	// inputs of http probes are urls
+	if parsed, err := url.Parse(result.Host); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		result.Host = parsed.Hostname()
+	}
+	return normalizeResult(result), nil
+}
+
+// csvHeader returns the column indexes of line when it is a csv header with an ip or host column
+func csvHeader(line string) map[string]int {
+	columns, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil
+	}
+	header := make(map[string]int, len(columns))
+	for i, column := range columns {
+		header[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	_, hasIP := header["ip"]
+	_, hasHost := header["host"]
+	if !hasIP && !hasHost {
+		return nil
+	}
+	return header
+}
+
+func (d *ResultDecoder) decodeCSV(line string) (Result, error) {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.FieldsPerRecord = -1
+	columns, err := reader.Read()
+	if err != nil {
+		return Result{}, err
+	}
+	value := func(name string) string {
+		if i, ok := d.header[name]; ok && i < len(columns) {
+			return strings.TrimSpace(columns[i])
+		}
+		return ""
+	}
+	result := Result{
+		Timestamp: parseTimestamp(value("timestamp")),
+		Source:    value("source"),
+		IP:        value("ip"),
+		Host:      value("host"),
+		Url:       value("url"),
+		Query:     value("query"),
+	}
+	result.Port, _ = strconv.Atoi(value("port"))
+	return normalizeResult(result), nil
+}
+
+// decodeLine decodes a url, host:port, host or ip
+func decodeLine(line string) Result {
+	var result Result
+	if parsed, err := url.Parse(line); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		result.Url = line
+		result.Host = parsed.Hostname()
+		if result.Port, _ = strconv.Atoi(parsed.Port()); result.Port == 0 {
+			switch strings.ToLower(parsed.Scheme) {
+			case "https":
+				result.Port = 443
+			case "http":
+				result.Port = 80
+			}
+		}
+		return normalizeResult(result)
+	}
+	if host, port, err := net.SplitHostPort(line); err == nil {
+		result.Host = host
+		result.Port, _ = strconv.Atoi(port)
+		return normalizeResult(result)
+	}
+	result.Host = strings.Trim(line, "[]")
+	return normalizeResult(result)
+}
+
+// normalizeResult sets the ip of results whose host is an ip, as engines do
+func normalizeResult(result Result) Result {
+	if result.IP == "" && net.ParseIP(result.Host) != nil {
+		result.IP = result.Host
+	}
+	return result
+}
+
+// parseTimestamp parses a unix or RFC3339 timestamp, 0 is returned for other values
+func parseTimestamp(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return unix
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.Unix()
+	}
+	return 0
+}
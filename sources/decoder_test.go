@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultDecoder(t *testing.T) {
+	input := strings.Join([]string{
+		`{"timestamp":1700000000,"source":"fofa","ip":"10.0.0.1","port":443,"host":"www.example.com","url":"","query":"title:admin"}`,
+		`{"timestamp":"2024-01-02T03:04:05Z","input":"https://api.example.com","a":["10.0.0.2"],"port":"8443","url":"https://api.example.com:8443"}`,
+		``,
+		`# comment`,
+		`https://10.0.0.3`,
+		`mail.example.com:25`,
+		`{"domain":"www.example.org","ip":"10.0.0.5"}`,
+		`timestamp,source,IP,port,host,url`,
+		`1700000000,shodan,10.0.0.4,22,10.0.0.4,`,
+	}, "\n")
+	decoder := NewResultDecoder(strings.NewReader(input))
+
+	var results []Result
+	for {
+		result, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		result.Raw = nil
+		results = append(results, result)
+	}
+	require.Equal(t, []Result{
+		{Timestamp: 1700000000, Source: "fofa", IP: "10.0.0.1", Port: 443, Host: "www.example.com", Query: "title:admin"},
+		{Timestamp: 1704164645, IP: "10.0.0.2", Port: 8443, Host: "api.example.com", Url: "https://api.example.com:8443"},
+		{IP: "10.0.0.3", Port: 443, Host: "10.0.0.3", Url: "https://10.0.0.3"},
+		{Port: 25, Host: "mail.example.com"},
+		{IP: "10.0.0.5", Host: "www.example.org"},
+		{Timestamp: 1700000000, Source: "shodan", IP: "10.0.0.4", Port: 22, Host: "10.0.0.4"},
+	}, results)
+
+	_, err := NewResultDecoder(strings.NewReader(`{"ip":`)).Decode()
+	require.NotNil(t, err)
+}
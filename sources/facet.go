@@ -0,0 +1,107 @@
+package sources
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultFacetSize is the number of values asked to the aggregation api of the engines
+var DefaultFacetSize = 50
+
+// FacetFields are the fields results can be aggregated on
+var FacetFields = []string{"port", "country", "product", "org", "title", "asn"}
+
+// Facet is the number of results of a query sharing the value of a field
+type Facet struct {
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	Count  int    `json:"count"`
+	Source string `json:"source"`
+	Query  string `json:"query,omitempty"`
+	// Filter is the value used to filter queries on the facet when it differs from Value
+	Filter string `json:"-"`
+	// Sampled is set when Count is counted from the fetched results instead of the whole result set
+	Sampled bool `json:"sampled,omitempty"`
+}
+
+// Faceter is implemented by agents aggregating results with the api of their engine,
+// the fields they don't support are counted from the fetched results
+type Faceter interface {
+	// FacetFields returns the fields of FacetFields the engine aggregates on
+	FacetFields() []string
+	// Facets counts the results of the index Query searches, the shards of queries are
+	// sized with them
+	Facets(session *Session, query *Query, field string) ([]Facet, error)
+}
+
+// IsFacetField returns true when results can be aggregated on field
+func IsFacetField(field string) bool {
+	return contains(FacetFields, field)
+}
+
+// facetKeys are the keys of the raw engine responses holding each facet field
+var facetKeys = map[string][]string{
+	"country": {"country", "country_name", "country_en", "country_cn", "country_code"},
+	"product": {"product", "app", "product_name", "server"},
+	"org":     {"org", "organization", "as_organization", "isp"},
+	"title":   {"title", "web_title", "html_title"},
+	"asn":     {"asn", "as_number", "asn_number"},
+}
+
+// FacetValue returns the value of field in result, the fields other than port are
+// searched in the raw response of the engine, empty when it is not found
+func FacetValue(result Result, field string) string {
+	if field == "port" {
+		if result.Port <= 0 {
+			return ""
+		}
+		return strconv.Itoa(result.Port)
+	}
+	if len(result.Raw) == 0 {
+		return ""
+	}
+	var raw interface{}
+	if err := json.Unmarshal(result.Raw, &raw); err != nil {
+		return ""
+	}
+	for _, key := range facetKeys[field] {
+		if value := findKey(raw, key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// findKey returns the first non empty string or number under key, searching nested objects breadth first
+func findKey(value interface{}, key string) string {
+	queue := []interface{}{value}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		switch v := current.(type) {
+		case map[string]interface{}:
+			switch found := v[key].(type) {
+			case string:
+				if found = strings.TrimSpace(found); found != "" {
+					return found
+				}
+			case float64:
+				return strconv.FormatFloat(found, 'f', -1, 64)
+			}
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			// sorted so that the same response always gives the same value
+			sort.Strings(keys)
+			for _, k := range keys {
+				queue = append(queue, v[k])
+			}
+		case []interface{}:
+			queue = append(queue, v...)
+		}
+	}
+	return ""
+}
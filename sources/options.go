@@ -0,0 +1,102 @@
+package sources
+
+import (
+	"sort"
+	"strings"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// Option is an engine specific option given with -eo engine.key=value
+type Option struct {
+	Name        string
+	Description string
+	// Values are the accepted values, any value is accepted when empty
+	Values []string
+}
+
+// Optioner is implemented by agents accepting engine specific options, the options
+// of a query are validated against the returned schema
+type Optioner interface {
+	Options() []Option
+}
+
+// BoolValues are the values of boolean options
+var BoolValues = []string{"true", "false"}
+
+// ParseEngineOptions parses engine.key=value values into the options of each engine
+func ParseEngineOptions(values []string) (map[string]map[string]string, error) {
+	options := make(map[string]map[string]string)
+	for _, value := range values {
+		name, optionValue, ok := strings.Cut(value, "=")
+		engine, key, hasKey := strings.Cut(strings.TrimSpace(name), ".")
+		engine, key = strings.ToLower(strings.TrimSpace(engine)), strings.TrimSpace(key)
+		if !ok || !hasKey || engine == "" || key == "" {
+			return nil, errorutil.NewWithTag("uncover", "invalid engine option %s, use engine.key=value (example: -eo hunter.is_web=1)", value)
+		}
+		if options[engine] == nil {
+			options[engine] = make(map[string]string)
+		}
+		options[engine][key] = strings.TrimSpace(optionValue)
+	}
+	return options, nil
+}
+
+// ValidateOptions returns an error when options are not declared by agent or have a value it doesn't accept
+func ValidateOptions(agent Agent, options map[string]string) error {
+	if len(options) == 0 {
+		return nil
+	}
+	var schema []Option
+	if optioner, ok := agent.(Optioner); ok {
+		schema = optioner.Options()
+	}
+	if len(schema) == 0 {
+		return errorutil.NewWithTag("uncover", "%s agent has no engine options", agent.Name())
+	}
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	// sorted so that the same options always give the same error
+	sort.Strings(keys)
+	for _, key := range keys {
+		option, ok := findOption(schema, key)
+		if !ok {
+			return errorutil.NewWithTag("uncover", "unknown %s option %s, supported options are %s", agent.Name(), key, strings.Join(optionNames(schema), ","))
+		}
+		if len(option.Values) == 0 {
+			continue
+		}
+		if !contains(option.Values, strings.ToLower(options[key])) {
+			return errorutil.NewWithTag("uncover", "invalid value %q of %s option %s, supported values are %s", options[key], agent.Name(), key, strings.Join(option.Values, ","))
+		}
+	}
+	return nil
+}
+
+func findOption(schema []Option, name string) (Option, bool) {
+	for _, option := range schema {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+func optionNames(schema []Option) []string {
+	names := make([]string, 0, len(schema))
+	for _, option := range schema {
+		names = append(names, option.Name)
+	}
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
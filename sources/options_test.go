@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type optionAgent struct{}
+
+func (agent *optionAgent) Name() string {
+	return "fake"
+}
+
+func (agent *optionAgent) Query(_ *Session, _ *Query) (chan Result, error) {
+	return nil, nil
+}
+
+func (agent *optionAgent) Options() []Option {
+	return []Option{
+		{Name: "full", Values: BoolValues},
+		{Name: "fields"},
+	}
+}
+
+func TestParseEngineOptions(t *testing.T) {
+	options, err := ParseEngineOptions([]string{"fofa.full=true", "FOFA.fields=title,country", "hunter.is_web = 1"})
+	require.Nil(t, err)
+	require.Equal(t, map[string]map[string]string{
+		"fofa":   {"full": "true", "fields": "title,country"},
+		"hunter": {"is_web": "1"},
+	}, options)
+
+	for _, invalid := range []string{"fofa.full", "full=true", ".full=true", "fofa.=true"} {
+		_, err := ParseEngineOptions([]string{invalid})
+		require.NotNil(t, err, invalid)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	agent := &optionAgent{}
+	require.Nil(t, ValidateOptions(agent, map[string]string{"full": "TRUE", "fields": "title"}))
+
+	err := ValidateOptions(agent, map[string]string{"size": "10"})
+	require.ErrorContains(t, err, "unknown fake option size, supported options are full,fields")
+
+	err = ValidateOptions(agent, map[string]string{"full": "yes"})
+	require.ErrorContains(t, err, `invalid value "yes" of fake option full`)
+
+	require.NotNil(t, ValidateOptions(&fakeAgent{}, map[string]string{"full": "true"}), "agents without schema have no options")
+}
+
+type fakeAgent struct{}
+
+func (agent *fakeAgent) Name() string {
+	return "plain"
+}
+
+func (agent *fakeAgent) Query(_ *Session, _ *Query) (chan Result, error) {
+	return nil, nil
+}
@@ -0,0 +1,85 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	iputil "github.com/projectdiscovery/utils/ip"
+	stringsutil "github.com/projectdiscovery/utils/strings"
+	util "github.com/wjlin0/uncover/utils"
+)
+
+// Pivot records which result led to a new query
+type Pivot struct {
+	Type  TargetType `json:"type"`
+	Value string     `json:"value"`
+	Depth int        `json:"depth"`
+	// Source is the agent which returned the result pivoted on
+	Source string `json:"source"`
+	// From is the host or ip:port of the result pivoted on
+	From string `json:"from"`
+}
+
+// ExtractPivots returns the targets worth a new search found in result:
+// its apex domain, the /24 of its ip and the certificate fingerprints,
+// organizations and favicon hashes found in the raw engine response
+func ExtractPivots(result Result) []Target {
+	var targets []Target
+	if host := strings.ToLower(result.Host); host != "" && util.IsValidDomain(host) {
+		targets = append(targets, Target{Value: util.GetMainDomain(host), Type: TargetDomain})
+	}
+	if ip := net.ParseIP(result.IP); ip != nil && iputil.IsIPv4(result.IP) {
+		targets = append(targets, Target{Value: fmt.Sprintf("%s/24", ip.Mask(net.CIDRMask(24, 32))), Type: TargetCIDR})
+	}
+	if len(result.Raw) == 0 {
+		return targets
+	}
+	var raw interface{}
+	if err := json.Unmarshal(result.Raw, &raw); err != nil {
+		return targets
+	}
+	walkRaw(raw, nil, func(path []string, value string) {
+		key := path[len(path)-1]
+		parents := strings.Join(path[:len(path)-1], ".")
+		switch {
+		case strings.Contains(parents, "favicon") || key == "icon_hash" || key == "iconhash":
+			// the field tells favicon hashes apart from other numbers
+			if faviconHashReg.MatchString(value) || md5Reg.MatchString(value) {
+				targets = append(targets, Target{Value: value, Type: TargetFavicon})
+			}
+		case strings.Contains(parents, "issuer"):
+			// issuer fields are shared by every certificate of a ca
+		case stringsutil.ContainsAny(parents, "cert", "ssl", "tls") && stringsutil.ContainsAny(key, "sha1", "sha256", "fingerprint"):
+			if FingerprintReg.MatchString(value) {
+				targets = append(targets, Target{Value: strings.ToLower(value), Type: TargetCert})
+			}
+		case stringsutil.ContainsAny(parents, "cert", "ssl", "tls") && (key == "o" || key == "org" || key == "organization"):
+			targets = append(targets, Target{Value: value, Type: TargetOrg})
+		}
+	})
+	return targets
+}
+
+// walkRaw calls callback with the lowercase key path of every scalar of raw
+func walkRaw(raw interface{}, path []string, callback func(path []string, value string)) {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			walkRaw(value, append(path[:len(path):len(path)], strings.ToLower(key)), callback)
+		}
+	case []interface{}:
+		for _, value := range v {
+			walkRaw(value, path, callback)
+		}
+	case nil:
+	default:
+		if len(path) == 0 {
+			return
+		}
+		if value := strings.TrimSpace(util.ToString(v)); value != "" {
+			callback(path, value)
+		}
+	}
+}
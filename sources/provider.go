@@ -21,22 +21,30 @@ var (
 	DefaultProviderConfigLocation = filepath.Join(UncoverConfigDir, "provider-config.yaml")
 )
 
+// Provider is the provider config, version 2 configures each engine under engines while
+// version 1 files only hold the flat key lists, which are still read
 type Provider struct {
-	Shodan     []string `yaml:"shodan"`
-	Censys     []string `yaml:"censys"`
-	Fofa       []string `yaml:"fofa"`
-	Quake      []string `yaml:"quake"`
-	Hunter     []string `yaml:"hunter"`
-	ZoomEye    []string `yaml:"zoomeye"`
-	Netlas     []string `yaml:"netlas"`
-	CriminalIP []string `yaml:"criminalip"`
-	Publicwww  []string `yaml:"publicwww"`
-	HunterHow  []string `yaml:"hunterhow"`
-	Binaryedge []string `yaml:"binaryedge"`
-	Github     []string `yaml:"github"`
-	FullHunt   []string `json:"fullhunt"`
-	Zone0      []string `json:"zone0"`
-	DayDayMap  []string `json:"daydaymap"`
+	Version int `yaml:"version,omitempty"`
+	// Engines are the settings of each engine by name, like its keys or the url of a private deployment
+	Engines map[string]*EngineConfig `yaml:"engines,omitempty"`
+
+	Shodan     []string `yaml:"shodan,omitempty"`
+	Censys     []string `yaml:"censys,omitempty"`
+	Fofa       []string `yaml:"fofa,omitempty"`
+	Quake      []string `yaml:"quake,omitempty"`
+	Hunter     []string `yaml:"hunter,omitempty"`
+	ZoomEye    []string `yaml:"zoomeye,omitempty"`
+	Netlas     []string `yaml:"netlas,omitempty"`
+	CriminalIP []string `yaml:"criminalip,omitempty"`
+	Publicwww  []string `yaml:"publicwww,omitempty"`
+	HunterHow  []string `yaml:"hunterhow,omitempty"`
+	Binaryedge []string `yaml:"binaryedge,omitempty"`
+	Github     []string `yaml:"github,omitempty"`
+	FullHunt   []string `yaml:"fullhunt,omitempty"`
+	Zone0      []string `yaml:"zone0,omitempty"`
+	DayDayMap  []string `yaml:"daydaymap,omitempty"`
+	// Cookies are the cookie files, cookies or tokens of the web accounts of spider agents by name
+	Cookies map[string]string `yaml:"cookies,omitempty"`
 }
 
 // NewProvider loads provider keys from default location and env variables
@@ -48,6 +56,8 @@ func NewProvider(location string) *Provider {
 	p := &Provider{}
 	if err := p.LoadProviderConfig(DefaultProviderConfigLocation); err != nil {
 		gologger.Error().Msgf("failed to load provider keys got %v", err)
+	} else if p.Version < ProviderConfigVersion {
+		gologger.Verbose().Msgf("provider config %s is a version 1 config, rewrite it with 'uncover migrate'", DefaultProviderConfigLocation)
 	}
 	p.LoadProviderKeysFromEnv()
 	return p
@@ -127,7 +137,10 @@ func (provider *Provider) LoadProviderConfig(location string) error {
 	if !fileutil.FileExists(location) {
 		return errorutil.NewWithTag("uncover", "provider config file %v does not exist", location)
 	}
-	return fileutil.Unmarshal(fileutil.YAML, []byte(location), provider)
+	if err := fileutil.Unmarshal(fileutil.YAML, []byte(location), provider); err != nil {
+		return err
+	}
+	return provider.normalize()
 }
 
 // LoadProviderKeysFromEnv loads provider keys from env variables
@@ -163,6 +176,16 @@ func (provider *Provider) LoadProviderKeysFromEnv() {
 	provider.Fofa = appendIfAllExists(provider.Fofa, "FOFA_EMAIL", "FOFA_KEY")
 	provider.Censys = appendIfAllExists(provider.Censys, "CENSYS_API_ID", "CENSYS_API_SECRET")
 	provider.DayDayMap = appendIfExists(provider.DayDayMap, "DAYDAYMAP_API_KEY")
+	setIfExists := func(agent string, envName string) {
+		if value, ok := os.LookupEnv(envName); ok && provider.Cookies[agent] == "" {
+			if provider.Cookies == nil {
+				provider.Cookies = make(map[string]string)
+			}
+			provider.Cookies[agent] = value
+		}
+	}
+	setIfExists("fofa-spider", "FOFA_COOKIE")
+	setIfExists("zoomeye-spider", "ZOOMEYE_COOKIE")
 }
 
 // HasKeys returns true if at least one agent/source has keys
@@ -194,7 +217,7 @@ func init() {
 	}
 	// create default provider file if it doesn't exist
 	if !fileutil.FileExists(DefaultProviderConfigLocation) {
-		if err := fileutil.Marshal(fileutil.YAML, []byte(DefaultProviderConfigLocation), Provider{}); err != nil {
+		if err := fileutil.Marshal(fileutil.YAML, []byte(DefaultProviderConfigLocation), defaultProviderConfig()); err != nil {
 			gologger.Warning().Msgf("couldn't write provider default file: %s\n", err)
 		}
 	}
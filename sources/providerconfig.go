@@ -0,0 +1,292 @@
+package sources
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/ratelimit"
+	"github.com/projectdiscovery/retryablehttp-go"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+)
+
+// ProviderConfigVersion is the version of the provider configs written by uncover
+const ProviderConfigVersion = 2
+
+// EngineConfig are the settings of an engine in the provider config
+type EngineConfig struct {
+	Keys []string `yaml:"keys,omitempty"`
+	// URL replaces the scheme and host of the requests of the engine, like a private or
+	// enterprise deployment, its path is prepended to the path of the requests
+	URL   string `yaml:"url,omitempty"`
+	Proxy string `yaml:"proxy,omitempty"`
+	// RateLimit is the maximum number of requests per second
+	RateLimit int `yaml:"rate-limit,omitempty"`
+	// PageSize is the number of results requested per page
+	PageSize int `yaml:"page-size,omitempty"`
+	// Timeout is the timeout of the requests in seconds
+	Timeout int `yaml:"timeout,omitempty"`
+	// Options are the default engine options, -eo options take precedence
+	Options map[string]string `yaml:"options,omitempty"`
+	// Cookies is the cookie file, cookies or token of the web account of a spider agent
+	Cookies string `yaml:"cookies,omitempty"`
+}
+
+// keyLists are the flat key lists of the engines using keys
+func (provider *Provider) keyLists() map[string]*[]string {
+	return map[string]*[]string{
+		"shodan":     &provider.Shodan,
+		"censys":     &provider.Censys,
+		"fofa":       &provider.Fofa,
+		"quake":      &provider.Quake,
+		"hunter":     &provider.Hunter,
+		"zoomeye":    &provider.ZoomEye,
+		"netlas":     &provider.Netlas,
+		"criminalip": &provider.CriminalIP,
+		"publicwww":  &provider.Publicwww,
+		"hunterhow":  &provider.HunterHow,
+		"binaryedge": &provider.Binaryedge,
+		"github":     &provider.Github,
+		"fullhunt":   &provider.FullHunt,
+		"zone0":      &provider.Zone0,
+		"daydaymap":  &provider.DayDayMap,
+	}
+}
+
+// Engine returns the settings of engine, nil when it has none
+func (provider *Provider) Engine(name string) *EngineConfig {
+	return provider.Engines[name]
+}
+
+// normalize adds the keys and cookies of the engines to the flat lists read by GetKeys
+func (provider *Provider) normalize() error {
+	if provider.Version > ProviderConfigVersion {
+		return errorutil.NewWithTag("uncover", "unsupported provider config version %d, the latest version is %d", provider.Version, ProviderConfigVersion)
+	}
+	lists := provider.keyLists()
+	for name, config := range provider.Engines {
+		if config == nil {
+			continue
+		}
+		if len(config.Keys) > 0 {
+			list, ok := lists[name]
+			if !ok {
+				return errorutil.NewWithTag("uncover", "%s engine doesn't use keys", name)
+			}
+			*list = sliceutil.Dedupe(append(*list, config.Keys...))
+		}
+		if config.Cookies != "" && provider.Cookies[name] == "" {
+			if provider.Cookies == nil {
+				provider.Cookies = make(map[string]string)
+			}
+			provider.Cookies[name] = config.Cookies
+		}
+	}
+	return nil
+}
+
+// defaultProviderConfig is the provider config written when there is none, with an
+// empty entry for each engine using keys
+func defaultProviderConfig() *Provider {
+	provider := &Provider{Version: ProviderConfigVersion, Engines: make(map[string]*EngineConfig)}
+	for name := range provider.keyLists() {
+		provider.Engines[name] = &EngineConfig{}
+	}
+	return provider
+}
+
+// MigrateProviderConfig rewrites the provider config at location to ProviderConfigVersion,
+// keeping the original file with a .bak extension. false is returned when the file
+// already is at the latest version
+func MigrateProviderConfig(location string) (bool, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return false, errorutil.NewWithErr(err).Msgf("could not read provider config %s", location)
+	}
+	old := &Provider{}
+	if err := fileutil.Unmarshal(fileutil.YAML, data, old); err != nil && err != io.EOF {
+		return false, errorutil.NewWithErr(err).Msgf("could not parse provider config %s", location)
+	}
+	if old.Version == ProviderConfigVersion {
+		return false, nil
+	}
+	if old.Version > ProviderConfigVersion {
+		return false, errorutil.NewWithTag("uncover", "unsupported provider config version %d, the latest version is %d", old.Version, ProviderConfigVersion)
+	}
+
+	migrated := defaultProviderConfig()
+	engine := func(name string) *EngineConfig {
+		if migrated.Engines[name] == nil {
+			migrated.Engines[name] = &EngineConfig{}
+		}
+		return migrated.Engines[name]
+	}
+	for name, config := range old.Engines {
+		if config != nil {
+			migrated.Engines[name] = config
+		}
+	}
+	for name, list := range old.keyLists() {
+		if keys := *list; len(keys) > 0 {
+			config := engine(name)
+			config.Keys = sliceutil.Dedupe(append(config.Keys, keys...))
+		}
+	}
+	for name, cookies := range old.Cookies {
+		if config := engine(name); config.Cookies == "" {
+			config.Cookies = cookies
+		}
+	}
+
+	if err := os.WriteFile(location+".bak", data, 0600); err != nil {
+		return false, errorutil.NewWithErr(err).Msgf("could not back up provider config %s", location)
+	}
+	file, err := os.Create(location)
+	if err != nil {
+		return false, errorutil.NewWithErr(err).Msgf("could not write provider config %s", location)
+	}
+	defer file.Close()
+	if err := fileutil.MarshalToWriter(fileutil.YAML, file, migrated); err != nil {
+		return false, errorutil.NewWithErr(err).Msgf("could not write provider config %s", location)
+	}
+	return true, nil
+}
+
+// engineSettings are the provider config settings of the requests of an engine
+type engineSettings struct {
+	endpoint *url.URL
+	proxy    *url.URL
+	timeout  time.Duration
+	limiter  *ratelimit.Limiter
+}
+
+// Configure sends the requests of engine with the url, proxy, rate limit and timeout of
+// its provider config settings
+func (s *Session) Configure(engine string, config *EngineConfig) error {
+	settings := &engineSettings{timeout: time.Duration(config.Timeout) * time.Second}
+	if config.URL != "" {
+		endpoint, err := url.Parse(config.URL)
+		if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+			return errorutil.NewWithTag("uncover", "invalid %s url %s", engine, config.URL)
+		}
+		settings.endpoint = endpoint
+	}
+	if config.Proxy != "" {
+		proxyURL, err := ParseProxy(config.Proxy, "")
+		if err != nil {
+			return err
+		}
+		settings.proxy = proxyURL
+	}
+	if config.RateLimit > 0 {
+		settings.limiter = ratelimit.New(context.Background(), uint(config.RateLimit), time.Second)
+	}
+	if settings.endpoint == nil && settings.proxy == nil && settings.timeout == 0 && settings.limiter == nil {
+		return nil
+	}
+	if s.engines == nil {
+		s.engines = make(map[string]*engineSettings)
+		s.Client.HTTPClient.Transport = &engineTransport{base: s.Client.HTTPClient.Transport, session: s, timeout: s.Client.HTTPClient.Timeout}
+	}
+	// the client timeout is the longest one, shorter ones are applied by engineTransport
+	if settings.timeout > s.Client.HTTPClient.Timeout {
+		s.Client.HTTPClient.Timeout = settings.timeout
+		if transport := s.transport(); transport != nil {
+			transport.ResponseHeaderTimeout = settings.timeout
+		}
+	}
+	s.engines[engine] = settings
+	return nil
+}
+
+// transport returns the http transport of the session, under the ones wrapping it
+func (s *Session) transport() *http.Transport {
+	roundTripper := s.Client.HTTPClient.Transport
+	for {
+		switch transport := roundTripper.(type) {
+		case *http.Transport:
+			return transport
+		case *engineTransport:
+			roundTripper = transport.base
+		case *poolTransport:
+			roundTripper = transport.base
+		default:
+			return nil
+		}
+	}
+}
+
+// takeRateLimit waits for the rate limit of source, the one of its settings when given
+func (s *Session) takeRateLimit(source string) error {
+	if settings, ok := s.engines[source]; ok && settings.limiter != nil {
+		settings.limiter.Take()
+		return nil
+	}
+	return s.RateLimits.Take(source)
+}
+
+// useEndpoint sends request to the url of the settings of source
+func (s *Session) useEndpoint(request *retryablehttp.Request, source string) {
+	settings, ok := s.engines[source]
+	if !ok || settings.endpoint == nil {
+		return
+	}
+	requestURL := request.Request.URL
+	requestURL.Scheme = settings.endpoint.Scheme
+	requestURL.Host = settings.endpoint.Host
+	if path := strings.TrimSuffix(settings.endpoint.Path, "/"); path != "" && !strings.HasPrefix(requestURL.Path, path+"/") {
+		requestURL.Path = path + requestURL.Path
+		requestURL.RawPath = ""
+	}
+	request.Request.Host = settings.endpoint.Host
+}
+
+// engineTransport applies the proxy and the timeout of the engine of each request
+type engineTransport struct {
+	base    http.RoundTripper
+	session *Session
+	// timeout is the timeout of the requests of engines without one
+	timeout time.Duration
+}
+
+func (t *engineTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	source, _ := request.Context().Value(sourceContextKey{}).(string)
+	timeout := t.timeout
+	if settings, ok := t.session.engines[source]; ok {
+		if settings.proxy != nil {
+			request = request.WithContext(context.WithValue(request.Context(), proxyContextKey{}, settings.proxy))
+		}
+		if settings.timeout > 0 {
+			timeout = settings.timeout
+		}
+	}
+	if timeout <= 0 {
+		return t.base.RoundTrip(request)
+	}
+	ctx, cancel := context.WithTimeout(request.Context(), timeout)
+	resp, err := t.base.RoundTrip(request.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody cancels the context of its request when closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,137 @@
+package sources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+)
+
+const providerConfigV1 = `shodan:
+  - shodan-key
+fofa:
+  - user@example.com:fofa-key
+fullhunt:
+  - fullhunt-key
+cookies:
+  fofa-spider: fofa_token=abc
+`
+
+func TestLoadProviderConfig(t *testing.T) {
+	dir := t.TempDir()
+	v1 := filepath.Join(dir, "v1.yaml")
+	require.Nil(t, os.WriteFile(v1, []byte(providerConfigV1), 0600))
+	provider := &Provider{}
+	require.Nil(t, provider.LoadProviderConfig(v1))
+	require.Equal(t, []string{"fullhunt-key"}, provider.FullHunt)
+	keys := provider.GetKeys()
+	require.Equal(t, "shodan-key", keys.Shodan)
+	require.Equal(t, "fofa-key", keys.FofaKey)
+
+	v2 := filepath.Join(dir, "v2.yaml")
+	require.Nil(t, os.WriteFile(v2, []byte(`version: 2
+engines:
+  hunter:
+    keys: [hunter-key]
+    url: https://hunter.example.com
+    page-size: 50
+    options:
+      is_web: "1"
+  zoomeye-spider:
+    cookies: token
+`), 0600))
+	provider = &Provider{}
+	require.Nil(t, provider.LoadProviderConfig(v2))
+	require.Equal(t, "hunter-key", provider.GetKeys().HunterToken)
+	require.Equal(t, "https://hunter.example.com", provider.Engine("hunter").URL)
+	require.Equal(t, 50, provider.Engine("hunter").PageSize)
+	require.Equal(t, "token", provider.Cookies["zoomeye-spider"])
+	require.Nil(t, provider.Engine("shodan"))
+
+	require.Nil(t, os.WriteFile(v2, []byte("version: 3\n"), 0600))
+	require.ErrorContains(t, (&Provider{}).LoadProviderConfig(v2), "unsupported provider config version 3")
+	require.Nil(t, os.WriteFile(v2, []byte("engines:\n  bing-spider:\n    keys: [key]\n"), 0600))
+	require.ErrorContains(t, (&Provider{}).LoadProviderConfig(v2), "bing-spider engine doesn't use keys")
+}
+
+func TestMigrateProviderConfig(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "provider-config.yaml")
+	require.Nil(t, os.WriteFile(location, []byte(providerConfigV1), 0600))
+
+	migrated, err := MigrateProviderConfig(location)
+	require.Nil(t, err)
+	require.True(t, migrated)
+	backup, err := os.ReadFile(location + ".bak")
+	require.Nil(t, err)
+	require.Equal(t, providerConfigV1, string(backup))
+
+	provider := &Provider{}
+	require.Nil(t, provider.LoadProviderConfig(location))
+	require.Equal(t, ProviderConfigVersion, provider.Version)
+	require.Equal(t, []string{"shodan-key"}, provider.Engine("shodan").Keys)
+	require.Equal(t, []string{"user@example.com:fofa-key"}, provider.Engine("fofa").Keys)
+	require.Equal(t, "fofa_token=abc", provider.Engine("fofa-spider").Cookies)
+	require.Equal(t, "shodan-key", provider.GetKeys().Shodan)
+
+	migrated, err = MigrateProviderConfig(location)
+	require.Nil(t, err)
+	require.False(t, migrated)
+
+	// relative paths are written too
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(wd) }()
+	require.Nil(t, os.MkdirAll("cfg", 0700))
+	for _, relative := range []string{"provider.yaml", "./provider.yaml", "cfg/provider.yaml"} {
+		require.Nil(t, os.WriteFile(relative, []byte(providerConfigV1), 0600))
+		migrated, err = MigrateProviderConfig(relative)
+		require.Nil(t, err)
+		require.True(t, migrated)
+		provider := &Provider{}
+		require.Nil(t, provider.LoadProviderConfig(relative))
+		require.Equal(t, ProviderConfigVersion, provider.Version, relative)
+	}
+}
+
+func TestSessionConfigure(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/slow") {
+			time.Sleep(2 * time.Second)
+		}
+	}))
+	defer ts.Close()
+
+	engines := []string{"fake-engine", "other-engine"}
+	session, err := NewSession(&Keys{}, 0, 5, 0, engines, time.Second, "", "")
+	require.Nil(t, err)
+	require.Nil(t, session.Configure(engines[0], &EngineConfig{URL: ts.URL + "/onprem", Timeout: 1, RateLimit: 100}))
+	require.ErrorContains(t, session.Configure(engines[1], &EngineConfig{URL: "hunter.example.com"}), "invalid other-engine url")
+
+	request, err := retryablehttp.NewRequest(http.MethodGet, "https://engine.example.com/api/search?q=1", nil)
+	require.Nil(t, err)
+	resp, err := session.Do(request, engines[0])
+	require.Nil(t, err)
+	_, err = ReadBody(resp)
+	require.Nil(t, err)
+	require.Equal(t, []string{"/onprem/api/search"}, paths)
+
+	// the timeout of an engine is shorter than the one of the session
+	request, err = retryablehttp.NewRequest(http.MethodGet, ts.URL+"/slow", nil)
+	require.Nil(t, err)
+	_, err = session.Do(request, engines[0])
+	require.NotNil(t, err)
+	request, err = retryablehttp.NewRequest(http.MethodGet, ts.URL+"/slow", nil)
+	require.Nil(t, err)
+	resp, err = session.Do(request, engines[1])
+	require.Nil(t, err)
+	resp.Body.Close()
+}
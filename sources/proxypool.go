@@ -0,0 +1,293 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+const (
+	// RotateRequest uses the next proxy of the pool for each request
+	RotateRequest = "request"
+	// RotateAgent keeps the same proxy for all the requests of an agent until it is dropped
+	RotateAgent = "agent"
+
+	// RouteDirect sends the requests of an engine without proxy
+	RouteDirect = "direct"
+	// RoutePool sends the requests of an engine through the proxy pool
+	RoutePool = "pool"
+
+	// DefaultProxyMaxFailures is the number of failures in a row after which a proxy is dropped
+	DefaultProxyMaxFailures = 3
+	// DefaultProxyCheckTimeout is the timeout of the check of a proxy when none is given
+	DefaultProxyCheckTimeout = 10 * time.Second
+)
+
+// DefaultProxyCheckURL is requested through each proxy of a pool to check it is alive
+var DefaultProxyCheckURL = "http://www.gstatic.com/generate_204"
+
+// proxySchemes are the proxy schemes supported by the http transport
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// ParseProxy parses a proxy url, http is used when it has no scheme and auth
+// (username:password) when it has no user
+func ParseProxy(value, auth string) (*url.URL, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil || proxyURL.Host == "" {
+		return nil, errorutil.NewWithTag("uncover", "invalid proxy %s", value)
+	}
+	proxyURL.Scheme = strings.ToLower(proxyURL.Scheme)
+	if !contains(proxySchemes, proxyURL.Scheme) {
+		return nil, errorutil.NewWithTag("uncover", "unsupported proxy scheme %s, supported schemes are %s", proxyURL.Scheme, strings.Join(proxySchemes, ","))
+	}
+	if auth != "" && proxyURL.User == nil {
+		username, password, ok := ParseProxyAuth(auth)
+		if !ok {
+			return nil, errorutil.NewWithTag("uncover", "invalid proxy auth, use username:password")
+		}
+		proxyURL.User = url.UserPassword(username, password)
+	}
+	return proxyURL, nil
+}
+
+// ReadProxyList reads the proxies of a file, one per line, skipping empty and # lines
+func ReadProxyList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not read proxy list %s", path)
+	}
+	defer file.Close()
+	var proxies []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		proxies = append(proxies, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not read proxy list %s", path)
+	}
+	if len(proxies) == 0 {
+		return nil, errorutil.NewWithTag("uncover", "no proxy in proxy list %s", path)
+	}
+	return proxies, nil
+}
+
+// ParseProxyRoutes parses engine=direct|pool values into the route of each engine,
+// the * engine is the route of the engines not given
+func ParseProxyRoutes(values []string) (map[string]string, error) {
+	routes := make(map[string]string)
+	for _, value := range values {
+		engine, route, ok := strings.Cut(value, "=")
+		engine, route = strings.ToLower(strings.TrimSpace(engine)), strings.ToLower(strings.TrimSpace(route))
+		if !ok || engine == "" || (route != RouteDirect && route != RoutePool) {
+			return nil, errorutil.NewWithTag("uncover", "invalid proxy route %s, use engine=direct or engine=pool (example: -proxy-route '*=direct,google-spider=pool')", value)
+		}
+		routes[engine] = route
+	}
+	return routes, nil
+}
+
+// ProxyPool rotates the requests of a session over proxies, dropping the ones failing
+// MaxFailures times in a row
+type ProxyPool struct {
+	// Rotation is RotateRequest or RotateAgent
+	Rotation string
+	// Routes are the routes of the engines, engines without route use the pool
+	Routes      map[string]string
+	MaxFailures int
+
+	mu      sync.Mutex
+	proxies []*poolProxy
+	next    int
+	sticky  map[string]*poolProxy
+}
+
+type poolProxy struct {
+	url      *url.URL
+	failures int
+	dropped  bool
+}
+
+// NewProxyPool returns a pool of proxies rotated per request
+func NewProxyPool(proxies []*url.URL) *ProxyPool {
+	pool := &ProxyPool{
+		Rotation:    RotateRequest,
+		MaxFailures: DefaultProxyMaxFailures,
+		sticky:      make(map[string]*poolProxy),
+	}
+	for _, proxyURL := range proxies {
+		pool.proxies = append(pool.proxies, &poolProxy{url: proxyURL})
+	}
+	return pool
+}
+
+// Route returns the route of engine
+func (p *ProxyPool) Route(engine string) string {
+	if route, ok := p.Routes[engine]; ok {
+		return route
+	}
+	if route, ok := p.Routes["*"]; ok {
+		return route
+	}
+	return RoutePool
+}
+
+// Alive returns the number of proxies not dropped
+func (p *ProxyPool) Alive() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	alive := 0
+	for _, proxy := range p.proxies {
+		if !proxy.dropped {
+			alive++
+		}
+	}
+	return alive
+}
+
+// Check requests checkURL through every proxy at the same time, dropping the ones
+// failing, and returns the number of proxies alive. DefaultProxyCheckTimeout is used
+// when timeout is 0
+func (p *ProxyPool) Check(ctx context.Context, checkURL string, timeout time.Duration) int {
+	if timeout <= 0 {
+		timeout = DefaultProxyCheckTimeout
+	}
+	wg := sync.WaitGroup{}
+	for _, proxy := range p.proxies {
+		wg.Add(1)
+		go func(proxy *poolProxy) {
+			defer wg.Done()
+			if err := checkProxy(ctx, proxy.url, checkURL, timeout); err != nil {
+				p.mu.Lock()
+				proxy.dropped = true
+				p.mu.Unlock()
+			}
+		}(proxy)
+	}
+	wg.Wait()
+	return p.Alive()
+}
+
+func checkProxy(ctx context.Context, proxyURL *url.URL, checkURL string, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyURL(proxyURL),
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, checkURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusProxyAuthRequired || resp.StatusCode >= http.StatusInternalServerError {
+		return errorutil.New("proxy %s check returned status %d", proxyURL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+// Next returns the proxy of the next request of engine, nil when engine is routed
+// directly. an error is returned when every proxy was dropped
+func (p *ProxyPool) Next(engine string) (*url.URL, error) {
+	if p.Route(engine) == RouteDirect {
+		return nil, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Rotation == RotateAgent {
+		if proxy, ok := p.sticky[engine]; ok && !proxy.dropped {
+			return proxy.url, nil
+		}
+	}
+	for i := 0; i < len(p.proxies); i++ {
+		proxy := p.proxies[p.next]
+		p.next = (p.next + 1) % len(p.proxies)
+		if proxy.dropped {
+			continue
+		}
+		if p.Rotation == RotateAgent {
+			p.sticky[engine] = proxy
+		}
+		return proxy.url, nil
+	}
+	return nil, errorutil.NewWithTag("uncover", "no proxy left in the proxy pool")
+}
+
+// Done records the outcome of a request through proxyURL, the proxy is dropped after
+// MaxFailures failures in a row
+func (p *ProxyPool) Done(proxyURL *url.URL, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, proxy := range p.proxies {
+		if proxy.url != proxyURL {
+			continue
+		}
+		if !failed {
+			proxy.failures = 0
+			return
+		}
+		proxy.failures++
+		maxFailures := p.MaxFailures
+		if maxFailures <= 0 {
+			maxFailures = DefaultProxyMaxFailures
+		}
+		if proxy.failures >= maxFailures {
+			proxy.dropped = true
+		}
+		return
+	}
+}
+
+type proxyContextKey struct{}
+
+// poolTransport sends requests through the proxy picked by its pool for their agent
+type poolTransport struct {
+	base *http.Transport
+	pool *ProxyPool
+}
+
+func (t *poolTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// requests of engines with their own proxy skip the pool
+	if proxyURL, _ := proxyFromContext(request); proxyURL != nil {
+		return t.base.RoundTrip(request)
+	}
+	source, _ := request.Context().Value(sourceContextKey{}).(string)
+	proxyURL, err := t.pool.Next(source)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return t.base.RoundTrip(request)
+	}
+	request = request.WithContext(context.WithValue(request.Context(), proxyContextKey{}, proxyURL))
+	resp, err := t.base.RoundTrip(request)
+	t.pool.Done(proxyURL, err != nil || resp.StatusCode == http.StatusProxyAuthRequired)
+	return resp, err
+}
+
+// proxyFromContext is the proxy set in the request context by the transport of a pool
+// or the settings of an engine
+func proxyFromContext(request *http.Request) (*url.URL, error) {
+	proxyURL, _ := request.Context().Value(proxyContextKey{}).(*url.URL)
+	return proxyURL, nil
+}
@@ -20,6 +20,12 @@ type Result struct {
 	Url       string `json:"url" csv:"url"`
 	Raw       []byte `json:"-" csv:"-"`
 	Error     error  `json:"-" csv:"-"`
+	// Query is the query sent to the engine which returned the result
+	Query string `json:"query,omitempty" csv:"-"`
+	// Pivot is set on results found by a recursive pivot query
+	Pivot *Pivot `json:"pivot,omitempty" csv:"-"`
+	// Change is set when diffing with a previous run (added, removed or changed)
+	Change string `json:"change,omitempty" csv:"-"`
 }
 
 func (result *Result) IpPort() string {
@@ -41,16 +47,7 @@ func (result *Result) JSON() string {
 func (result *Result) CSV() string {
 	buffer := bytes.Buffer{}
 	encoder := csv.NewWriter(&buffer)
-	var fields []string
-	vl := reflect.ValueOf(*result)
-	ty := vl.Type()
-	for i := 0; i < vl.NumField(); i++ {
-		if ty.Field(i).Tag.Get("csv") != "-" {
-			fields = append(fields, fmt.Sprint(vl.Field(i).Interface()))
-		}
-
-	}
-	if err := encoder.Write(fields); err != nil {
+	if err := encoder.Write(result.CSVValues()); err != nil {
 		return ""
 	}
 	encoder.Flush()
@@ -60,6 +57,15 @@ func (result *Result) CSV() string {
 func (result *Result) CSVHeader() (string, error) {
 	buffer := bytes.Buffer{}
 	writer := csv.NewWriter(&buffer)
+	if err := writer.Write(result.CSVHeaders()); err != nil {
+		return "", errors.Wrap(err, "Could not write headers")
+	}
+	writer.Flush()
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// CSVHeaders returns the csv names of the fields written in csv output
+func (result *Result) CSVHeaders() []string {
 	ty := reflect.TypeOf(*result)
 	var headers []string
 	for i := 0; i < ty.NumField(); i++ {
@@ -67,10 +73,18 @@ func (result *Result) CSVHeader() (string, error) {
 			headers = append(headers, ty.Field(i).Tag.Get("csv"))
 		}
 	}
+	return headers
+}
 
-	if err := writer.Write(headers); err != nil {
-		return "", errors.Wrap(err, "Could not write headers")
+// CSVValues returns the values of the fields written in csv output, in the order of CSVHeaders
+func (result *Result) CSVValues() []string {
+	vl := reflect.ValueOf(*result)
+	ty := vl.Type()
+	var fields []string
+	for i := 0; i < vl.NumField(); i++ {
+		if ty.Field(i).Tag.Get("csv") != "-" {
+			fields = append(fields, fmt.Sprint(vl.Field(i).Interface()))
+		}
 	}
-	writer.Flush()
-	return strings.TrimSpace(buffer.String()), nil
+	return fields
 }
@@ -0,0 +1,91 @@
+package searchengine
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/wjlin0/uncover/sources"
+	util "github.com/wjlin0/uncover/utils"
+)
+
+// Parser returns the subdomains of domain in a page of results
+type Parser interface {
+	Parse(domain string, body []byte) []string
+}
+
+// ParserFunc is a function used as a Parser
+type ParserFunc func(domain string, body []byte) []string
+
+func (f ParserFunc) Parse(domain string, body []byte) []string {
+	return f(domain, body)
+}
+
+// RegexParser matches the subdomains of domain anywhere in the page
+type RegexParser struct {
+	// Unescape url decodes the page first
+	Unescape bool
+	// Strip are removed from the page before matching, like the highlighting tags of matches
+	Strip []string
+	// Fuzzy matches subdomains outside of urls too
+	Fuzzy bool
+}
+
+func (p *RegexParser) Parse(domain string, body []byte) []string {
+	page := string(body)
+	if p.Unescape {
+		if unescaped, err := url.QueryUnescape(page); err == nil {
+			page = unescaped
+		}
+	}
+	for _, strip := range p.Strip {
+		page = strings.ReplaceAll(page, strip, "")
+	}
+	var hosts []string
+	for _, match := range sources.MatchSubdomains(domain, page, p.Fuzzy) {
+		_, host, _ := util.GetProtocolHostAndPort(match)
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// XPathParser matches the subdomains of domain in the nodes selected by an xpath expression
+type XPathParser struct {
+	Expr string
+	// Resolve returns the subdomains of a selected link, like the target of a redirect
+	// link. the text of the nodes is matched when nil
+	Resolve func(link string) []string
+}
+
+func (p *XPathParser) Parse(domain string, body []byte) []string {
+	doc, err := htmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	nodes, err := htmlquery.QueryAll(doc, p.Expr)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	var hosts []string
+	for _, node := range nodes {
+		text := strings.TrimSpace(htmlquery.InnerText(node))
+		if text == "" {
+			continue
+		}
+		matches := sources.MatchSubdomains(domain, text, true)
+		if p.Resolve != nil {
+			matches = p.Resolve(text)
+		}
+		for _, match := range matches {
+			_, host, _ := util.GetProtocolHostAndPort(match)
+			if _, ok := seen[host]; ok {
+				continue
+			}
+			seen[host] = struct{}{}
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
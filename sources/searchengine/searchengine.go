@@ -0,0 +1,248 @@
+// Package searchengine scrapes web search engines for the subdomains of a domain with
+// site: queries, excluding the subdomains already found to surface new ones
+package searchengine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wjlin0/uncover/sources"
+	util "github.com/wjlin0/uncover/utils"
+)
+
+// DefaultRounds is the number of searches excluding the subdomains already found
+const DefaultRounds = 10
+
+// Engine is a web search engine and how its result pages are requested and parsed
+type Engine struct {
+	Name string
+	// InitURL is requested first for the cookies of the engine, skipped when empty
+	InitURL string
+	// SearchURL returns the url of a page of results
+	SearchURL func(page Page) string
+	// Headers are sent with every request
+	Headers map[string]string
+	// Cookies are sent with every request along with the cookies set by InitURL
+	Cookies []*http.Cookie
+	// PerPage is the number of results asked per page
+	PerPage int
+	// MaxOffset is the offset after which no more page is requested
+	MaxOffset int
+	// MaxQueryLength and MaxQueryTerms bound the queries with exclusions, 0 is no bound
+	MaxQueryLength int
+	MaxQueryTerms  int
+	// Rounds is the number of searches excluding the subdomains found, DefaultRounds when 0
+	Rounds int
+	Parser Parser
+	// HasNext returns true when body links to the page after page
+	HasNext func(body string, page Page) bool
+	// BlockRules match the captcha and consent pages of the engine, along with sources.DefaultBlockRules
+	BlockRules []sources.BlockRule
+	// Retries and Backoff are sources.DefaultBlockRetries and sources.DefaultBlockBackoff when 0
+	Retries int
+	Backoff time.Duration
+}
+
+// Page is a page of results of a query
+type Page struct {
+	Domain string
+	// Query is the site: query of the domain with its exclusions
+	Query   string
+	Offset  int
+	PerPage int
+	// Locale is the locale engine option of the query, empty for the default one
+	Locale string
+}
+
+// Search sends the subdomains of query found by engine to results, pages are requested
+// until one has no new subdomain, has no next page or the limit of query is reached.
+// blocked pages are retried with new cookies, a sources.BlockedError is sent when the
+// engine keeps blocking
+func Search(session *sources.Session, engine *Engine, query *sources.Query, results chan sources.Result) {
+	rounds := engine.Rounds
+	if rounds <= 0 {
+		rounds = DefaultRounds
+	}
+	s := &search{
+		engine:  engine,
+		session: session,
+		query:   query,
+		results: results,
+		seen:    make(map[string]int),
+	}
+	if err := s.bootstrap(); err != nil {
+		results <- sources.Result{Source: engine.Name, Error: err}
+		return
+	}
+	base := "site:." + query.Query
+	if !s.run(base) {
+		return
+	}
+	previous := base
+	for round := 0; round < rounds && !s.full(); round++ {
+		q := Exclude(base, s.frequent(), engine.MaxQueryLength, engine.MaxQueryTerms)
+		if q == previous || !s.run(q) {
+			return
+		}
+		previous = q
+	}
+}
+
+// Exclude returns query followed by a -site: exclusion of each host while the query fits
+// under maxLength characters and maxTerms words, 0 is no bound
+func Exclude(query string, hosts []string, maxLength, maxTerms int) string {
+	terms := len(strings.Fields(query))
+	for _, host := range hosts {
+		exclusion := " -site:" + host
+		if maxLength > 0 && len(query)+len(exclusion) > maxLength {
+			break
+		}
+		if maxTerms > 0 && terms+1 > maxTerms {
+			break
+		}
+		query += exclusion
+		terms++
+	}
+	return query
+}
+
+type search struct {
+	engine  *Engine
+	session *sources.Session
+	query   *sources.Query
+	cookies []*http.Cookie
+	results chan sources.Result
+	// seen is the number of pages each subdomain was found on
+	seen map[string]int
+	// searches are the queries searched without being blocked
+	searches int
+	blocked  bool
+}
+
+// bootstrap gets new cookies of the engine from its InitURL
+func (s *search) bootstrap() error {
+	s.cookies = s.engine.Cookies
+	if s.engine.InitURL == "" {
+		return nil
+	}
+	resp, err := s.engine.do(s.session, s.engine.InitURL, nil)
+	if err != nil {
+		return fmt.Errorf("could not get %s cookies: %w", s.engine.Name, err)
+	}
+	resp.Body.Close()
+	s.cookies = append(resp.Cookies(), s.engine.Cookies...)
+	return nil
+}
+
+// run pages through the results of q and returns true when a new subdomain was found
+func (s *search) run(q string) bool {
+	found := false
+	defer func() {
+		if !s.blocked {
+			s.searches++
+		}
+	}()
+	page := Page{Domain: s.query.Query, Query: q, PerPage: s.engine.PerPage, Locale: s.query.Options["locale"]}
+	for !s.full() {
+		body, err := s.fetch(page)
+		if err != nil {
+			s.results <- sources.Result{Source: s.engine.Name, Error: err}
+			return false
+		}
+		hosts := s.engine.Parser.Parse(page.Domain, body.Bytes())
+		if !s.add(hosts) {
+			return found
+		}
+		found = true
+		page.Offset += page.PerPage
+		if !s.engine.HasNext(body.String(), page) || (s.engine.MaxOffset > 0 && page.Offset > s.engine.MaxOffset) {
+			return found
+		}
+	}
+	return found
+}
+
+// fetch returns the body of page, a blocked page is requested again after a backoff
+// and new cookies until the retries run out
+func (s *search) fetch(page Page) (*bytes.Buffer, error) {
+	retry := &sources.BlockRetry{
+		Source:    s.engine.Name,
+		Rules:     s.engine.BlockRules,
+		Bootstrap: s.bootstrap,
+		Retries:   s.engine.Retries,
+		Backoff:   s.engine.Backoff,
+	}
+	URL := s.engine.SearchURL(page)
+	_, body, err := retry.Fetch(URL, func() (*http.Response, error) {
+		return s.engine.do(s.session, URL, s.cookies)
+	})
+	if blocked, ok := sources.IsBlocked(err); ok {
+		s.blocked = true
+		blocked.Query, blocked.Results, blocked.Searches = s.query.Query, len(s.seen), s.searches+1
+	}
+	return body, err
+}
+
+// add sends the new hosts as results and returns false when there was none
+func (s *search) add(hosts []string) bool {
+	added := false
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		s.seen[host]++
+		if s.seen[host] > 1 || s.full() {
+			continue
+		}
+		added = true
+		protocol, hostname, port := util.GetProtocolHostAndPort(host)
+		result := sources.Result{Source: s.engine.Name, Host: hostname, Port: port}
+		result.Url = fmt.Sprintf("%s://%s:%d", protocol, hostname, port)
+		raw, _ := json.Marshal(result)
+		result.Raw = raw
+		s.results <- result
+	}
+	return added
+}
+
+func (s *search) full() bool {
+	return s.query.Limit > 0 && len(s.seen) >= s.query.Limit
+}
+
+// frequent returns the subdomains found, the ones found on the most pages first as
+// excluding them leaves the most room for new ones
+func (s *search) frequent() []string {
+	hosts := make([]string, 0, len(s.seen))
+	for host := range s.seen {
+		if host != s.query.Query {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if s.seen[hosts[i]] != s.seen[hosts[j]] {
+			return s.seen[hosts[i]] > s.seen[hosts[j]]
+		}
+		return hosts[i] < hosts[j]
+	})
+	return hosts
+}
+
+func (engine *Engine) do(session *sources.Session, URL string, cookies []*http.Cookie) (*http.Response, error) {
+	request, err := sources.NewHTTPRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range engine.Headers {
+		request.Header.Set(key, value)
+	}
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	return session.Do(request, engine.Name)
+}
@@ -0,0 +1,163 @@
+package searchengine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wjlin0/uncover/sources"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.Nil(t, err)
+	return body
+}
+
+func TestParsers(t *testing.T) {
+	body := fixture(t, "page1.html")
+
+	hosts := (&RegexParser{}).Parse("example.com", body)
+	sort.Strings(hosts)
+	require.Equal(t, []string{"mail.example.com", "www.example.com"}, hosts)
+
+	hosts = (&XPathParser{Expr: "//ol/li/a/@href"}).Parse("example.com", body)
+	require.Equal(t, []string{"www.example.com", "mail.example.com"}, hosts)
+
+	hosts = (&XPathParser{Expr: "//ol/li/a/@href", Resolve: func(link string) []string {
+		return []string{"resolved.example.com"}
+	}}).Parse("example.com", body)
+	require.Equal(t, []string{"resolved.example.com"}, hosts)
+}
+
+func TestExclude(t *testing.T) {
+	hosts := []string{"www.example.com", "mail.example.com", "api.example.com"}
+	require.Equal(t, "site:.example.com -site:www.example.com -site:mail.example.com -site:api.example.com", Exclude("site:.example.com", hosts, 0, 0))
+	require.Equal(t, "site:.example.com -site:www.example.com", Exclude("site:.example.com", hosts, 60, 0))
+	require.Equal(t, "site:.example.com -site:www.example.com -site:mail.example.com", Exclude("site:.example.com", hosts, 0, 3))
+}
+
+func TestSearch(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		queries = append(queries, q)
+		switch {
+		case strings.Contains(q, "-site:"):
+			_, _ = w.Write(fixture(t, "excluded.html"))
+		case r.URL.Query().Get("first") == "0":
+			_, _ = w.Write(fixture(t, "page1.html"))
+		default:
+			_, _ = w.Write(fixture(t, "page2.html"))
+		}
+	}))
+	defer ts.Close()
+
+	engine := &Engine{
+		Name: "test",
+		SearchURL: func(page Page) string {
+			return ts.URL + "/search?q=" + strings.ReplaceAll(page.Query, " ", "+") + "&first=" + strconv.Itoa(page.Offset)
+		},
+		PerPage: 1,
+		Parser:  &RegexParser{},
+		HasNext: func(body string, page Page) bool {
+			return strings.Contains(body, "first=")
+		},
+	}
+	session, err := sources.NewSession(&sources.Keys{}, 0, 5, 60, []string{"test"}, time.Second, "", "")
+	require.Nil(t, err)
+
+	results := make(chan sources.Result)
+	go func() {
+		defer close(results)
+		Search(session, engine, &sources.Query{Query: "example.com", Limit: 10}, results)
+	}()
+	var hosts []string
+	for result := range results {
+		require.Nil(t, result.Error)
+		hosts = append(hosts, result.Host)
+	}
+	require.Equal(t, []string{"mail.example.com", "www.example.com", "api.example.com", "dev.example.com"}, sortFirst(hosts, 2))
+	// www.example.com was found on both pages so it is excluded first
+	require.Equal(t, []string{
+		"site:.example.com",
+		"site:.example.com",
+		"site:.example.com -site:www.example.com -site:api.example.com -site:mail.example.com",
+		"site:.example.com -site:www.example.com -site:api.example.com -site:dev.example.com -site:mail.example.com",
+	}, queries)
+}
+
+// sortFirst sorts the first n hosts, found on the same page in no particular order
+func sortFirst(hosts []string, n int) []string {
+	sort.Strings(hosts[:n])
+	return hosts
+}
+
+func TestSearchBlocked(t *testing.T) {
+	var bootstraps, searches int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			bootstraps++
+			return
+		}
+		searches++
+		// the first search is blocked once, the exclusion searches always
+		if searches == 1 || strings.Contains(r.URL.Query().Get("q"), "-site:") {
+			w.Header().Set("Location", "/sorry/index")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+		_, _ = w.Write(fixture(t, "page2.html"))
+	}))
+	defer ts.Close()
+
+	engine := &Engine{
+		Name:    "test",
+		InitURL: ts.URL + "/",
+		SearchURL: func(page Page) string {
+			return ts.URL + "/search?q=" + strings.ReplaceAll(page.Query, " ", "+")
+		},
+		PerPage:    10,
+		Rounds:     2,
+		Parser:     &RegexParser{},
+		HasNext:    func(body string, page Page) bool { return false },
+		BlockRules: []sources.BlockRule{{Reason: "captcha", Location: "/sorry/"}},
+		Retries:    1,
+		Backoff:    time.Millisecond,
+	}
+	session, err := sources.NewSession(&sources.Keys{}, 0, 5, 60, []string{"test"}, time.Second, "", "")
+	require.Nil(t, err)
+
+	results := make(chan sources.Result)
+	go func() {
+		defer close(results)
+		Search(session, engine, &sources.Query{Query: "example.com", Limit: 10}, results)
+	}()
+	var hosts []string
+	var blocked *sources.BlockedError
+	for result := range results {
+		if result.Error != nil {
+			var ok bool
+			blocked, ok = sources.IsBlocked(result.Error)
+			require.True(t, ok)
+			continue
+		}
+		hosts = append(hosts, result.Host)
+	}
+	sort.Strings(hosts)
+	require.Equal(t, []string{"api.example.com", "www.example.com"}, hosts)
+	require.NotNil(t, blocked)
+	require.Equal(t, "captcha", blocked.Reason)
+	require.Equal(t, 2, blocked.Results)
+	// the first search went through after a retry, the second was blocked
+	require.Equal(t, 2, blocked.Searches)
+	// the cookies are bootstrapped again before each retry
+	require.Equal(t, 3, bootstraps)
+}
@@ -53,8 +53,17 @@ type Session struct {
 	Client     *retryablehttp.Client
 	RetryMax   int
 	RateLimits *ratelimit.MultiLimiter
+	// Proxies is the proxy pool of the requests, nil when not used
+	Proxies *ProxyPool
+	// accounts are the web accounts of the spider agents by name
+	accounts map[string]*accountJar
+	// engines are the provider config settings of the engines by name
+	engines map[string]*engineSettings
 }
 
+// sourceContextKey is the key of the agent of a request in its context
+type sourceContextKey struct{}
+
 func ParseProxyAuth(auth string) (string, string, bool) {
 	parts := strings.SplitN(auth, ":", 2)
 	if len(parts) != 2 || parts[0] == "" {
@@ -101,7 +110,13 @@ func NewSession(keys *Keys, retryMax, timeout, rateLimit int, engines []string,
 			InsecureSkipVerify: true,
 		},
 		ResponseHeaderTimeout: time.Duration(timeout) * time.Second,
-		Proxy:                 proxyFunc,
+		// the proxy of the settings of an engine is used instead of the one of the session
+		Proxy: func(request *http.Request) (*url.URL, error) {
+			if proxyURL, _ := proxyFromContext(request); proxyURL != nil {
+				return proxyURL, nil
+			}
+			return proxyFunc(request)
+		},
 	}
 
 	httpclient := &http.Client{
@@ -151,17 +166,39 @@ func NewSession(keys *Keys, retryMax, timeout, rateLimit int, engines []string,
 	return session, nil
 }
 
+// UseProxyPool sends the requests of the session through the proxies of pool, routed
+// by the agent of each request
+func (s *Session) UseProxyPool(pool *ProxyPool) {
+	transport := s.transport()
+	if transport == nil {
+		return
+	}
+	base := transport.Clone()
+	base.Proxy = proxyFromContext
+	pooled := &poolTransport{base: base, pool: pool}
+	if engines, ok := s.Client.HTTPClient.Transport.(*engineTransport); ok {
+		engines.base = pooled
+	} else {
+		s.Client.HTTPClient.Transport = pooled
+	}
+	s.Proxies = pool
+}
+
 func (s *Session) Do(request *retryablehttp.Request, source string) (*http.Response, error) {
-	err := s.RateLimits.Take(source)
+	err := s.takeRateLimit(source)
 	if err != nil {
 		return nil, err
 	}
+	s.useEndpoint(request, source)
+	request = request.WithContext(context.WithValue(request.Context(), sourceContextKey{}, source))
+	s.addCredentials(source, request.Request)
 	// close request connection (does not reuse connections)
 	request.Close = true
 	resp, err := s.Client.Do(request)
 	if err != nil {
 		return nil, err
 	}
+	s.refreshCredentials(source, resp)
 	//if resp.StatusCode != http.StatusOK {
 	//	requestURL, _ := url.QueryUnescape(request.URL.String())
 	//	return resp, fmt.Errorf("unexpected status code %d received from %s", resp.StatusCode, requestURL)
@@ -0,0 +1,66 @@
+package sources
+
+import "fmt"
+
+// ShardFields are the fields queries over the result cap of an engine are split on, in
+// order. time halves the time range on engines filtering by time
+var ShardFields = []string{"country", "port", "time", "asn"}
+
+// ShardSyntax is how an engine filters a query on the value of a field
+type ShardSyntax struct {
+	// And adds a filter to a query
+	And string
+	// Filters are the filter of each shard field
+	Filters map[string]string
+	// AndNot adds the exclusion of a value to a query, the one of Excludes when the
+	// field has one and of Filters otherwise
+	AndNot   string
+	Excludes map[string]string
+}
+
+// DefaultShardSyntax of the engines results can be sharded on
+var DefaultShardSyntax = map[string]ShardSyntax{
+	"fofa": {
+		And: `(%s) && %s`, Filters: map[string]string{"country": `country="%s"`, "port": `port="%s"`, "asn": `asn="%s"`},
+		AndNot: `(%s) && %s`, Excludes: map[string]string{"country": `country!="%s"`, "port": `port!="%s"`, "asn": `asn!="%s"`},
+	},
+	"hunter": {
+		And: `(%s)&&%s`, Filters: map[string]string{"country": `ip.country="%s"`, "port": `ip.port="%s"`, "asn": `as.number="%s"`},
+		AndNot: `(%s)&&%s`, Excludes: map[string]string{"country": `ip.country!="%s"`, "port": `ip.port!="%s"`, "asn": `as.number!="%s"`},
+	},
+	"quake":   {And: `(%s) AND %s`, AndNot: `(%s) AND NOT %s`, Filters: map[string]string{"country": `country:"%s"`, "port": `port:%s`, "asn": `asn:%s`}},
+	"zoomeye": {And: `%s +%s`, AndNot: `%s -%s`, Filters: map[string]string{"country": `country:"%s"`, "port": `port:%s`, "asn": `asn:%s`}},
+}
+
+// ShardQuery returns query restricted to the results having value as field on engine,
+// false is returned when engine can't filter on field
+func ShardQuery(engine, query, field, value string) (string, bool) {
+	syntax, ok := DefaultShardSyntax[engine]
+	if !ok {
+		return "", false
+	}
+	filter, ok := syntax.Filters[field]
+	if !ok || value == "" {
+		return "", false
+	}
+	return fmt.Sprintf(syntax.And, query, fmt.Sprintf(filter, value)), true
+}
+
+// ExcludeQuery returns query restricted to the results having none of values as field
+// on engine, false is returned when engine can't exclude values of field
+func ExcludeQuery(engine, query, field string, values []string) (string, bool) {
+	syntax, ok := DefaultShardSyntax[engine]
+	if !ok || syntax.AndNot == "" || len(values) == 0 {
+		return "", false
+	}
+	filter, ok := syntax.Excludes[field]
+	if !ok {
+		if filter, ok = syntax.Filters[field]; !ok {
+			return "", false
+		}
+	}
+	for _, value := range values {
+		query = fmt.Sprintf(syntax.AndNot, query, fmt.Sprintf(filter, value))
+	}
+	return query, true
+}
@@ -0,0 +1,272 @@
+package sources
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// TargetType is the kind of input given to the agents
+type TargetType string
+
+const (
+	TargetDomain  TargetType = "domain"
+	TargetIP      TargetType = "ip"
+	TargetCIDR    TargetType = "cidr"
+	TargetASN     TargetType = "asn"
+	TargetFavicon TargetType = "favicon"
+	TargetCert    TargetType = "cert"
+	// TargetOrg is a certificate subject organization, it is never
+	// detected by ClassifyTarget and only produced by certificate pivots
+	TargetOrg TargetType = "org"
+	// TargetRaw is a query written in the syntax of the engine
+	TargetRaw TargetType = "query"
+)
+
+var (
+	asnReg         = regexp.MustCompile(`(?i)^AS(\d{1,10})$`)
+	faviconHashReg = regexp.MustCompile(`^-?\d{1,10}$`)
+	// favicon targets are told apart from ports and asns by a sign or a favicon: or mmh3: prefix
+	faviconTargetReg = regexp.MustCompile(`(?i)^(?:(?:favicon|mmh3):\s*[+-]?\d{1,10}|favicon:\s*[a-f0-9]{32}|[+-]\d{1,10})$`)
+	faviconPrefixReg = regexp.MustCompile(`(?i)^(?:favicon|mmh3):\s*`)
+	md5Reg           = regexp.MustCompile(`(?i)^[a-f0-9]{32}$`)
+	// FingerprintReg matches the sha1 and sha256 fingerprints of certificates
+	FingerprintReg  = regexp.MustCompile(`(?i)^([a-f0-9]{40}|[a-f0-9]{64})$`)
+	targetDomainReg = regexp.MustCompile(`(?i)^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+)
+
+// AllTargets are supported by engines with a full query language
+var AllTargets = []TargetType{TargetDomain, TargetIP, TargetCIDR, TargetASN, TargetFavicon, TargetCert, TargetOrg, TargetRaw}
+
+// Target is an input of a known kind
+type Target struct {
+	Value string
+	Type  TargetType
+}
+
+// Capable is implemented by agents searching other kinds of targets than raw queries
+type Capable interface {
+	Capabilities() []TargetType
+}
+
+// CapabilitiesOf returns the kinds of targets agent can handle, agents which aren't
+// Capable only accept raw queries
+func CapabilitiesOf(agent Agent) []TargetType {
+	if capable, ok := agent.(Capable); ok {
+		return capable.Capabilities()
+	}
+	return []TargetType{TargetRaw}
+}
+
+// ClassifyTarget returns the kind of target
+func ClassifyTarget(target string) TargetType {
+	target = strings.TrimSpace(target)
+	switch {
+	case iputil.IsIP(target):
+		return TargetIP
+	case iputil.IsCIDR(target):
+		return TargetCIDR
+	case asnReg.MatchString(target):
+		return TargetASN
+	case faviconTargetReg.MatchString(target), md5Reg.MatchString(target):
+		return TargetFavicon
+	case FingerprintReg.MatchString(target):
+		return TargetCert
+	case targetDomainReg.MatchString(target):
+		return TargetDomain
+	default:
+		return TargetRaw
+	}
+}
+
+// Supports returns true if agent is able to handle targets of kind targetType
+func Supports(agent Agent, targetType TargetType) bool {
+	for _, capability := range CapabilitiesOf(agent) {
+		if capability == targetType {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetQuery translates target of kind targetType into the query syntax of agent.
+// false is returned when the agent cannot handle that kind of target
+func TargetQuery(target string, targetType TargetType, agent Agent) (string, bool) {
+	if !Supports(agent, targetType) {
+		return "", false
+	}
+	target = strings.TrimSpace(target)
+	syntax := strings.TrimSuffix(agent.Name(), "-spider")
+	switch targetType {
+	case TargetDomain:
+		return domainQuery(target, syntax), true
+	case TargetIP, TargetCIDR:
+		return ipQuery(target, syntax), true
+	case TargetASN:
+		return asnQuery(asnReg.FindStringSubmatch(target)[1], syntax), true
+	case TargetFavicon:
+		return FaviconQuery(faviconHash(target), syntax)
+	case TargetCert:
+		return CertQuery(target, syntax)
+	case TargetOrg:
+		return orgQuery(target, syntax)
+	default:
+		return target, true
+	}
+}
+
+func domainQuery(domain, engine string) string {
+	switch engine {
+	case "censys":
+		return fmt.Sprintf("dns.names: %s", domain)
+	case "netlas":
+		return fmt.Sprintf("domain:*.%s", domain)
+	case "hunterhow", "daydaymap":
+		return fmt.Sprintf("domain=\"%s\"", domain)
+	case "zone0":
+		return fmt.Sprintf("domain==%s", domain)
+	default:
+		return DefaultQuery(domain, engine)
+	}
+}
+
+func ipQuery(ip, engine string) string {
+	switch engine {
+	case "fofa", "hunter", "hunterhow", "daydaymap":
+		return fmt.Sprintf("ip=\"%s\"", ip)
+	case "quake":
+		return fmt.Sprintf("ip:\"%s\"", ip)
+	case "shodan":
+		if iputil.IsCIDR(ip) {
+			return fmt.Sprintf("net:%s", ip)
+		}
+		return fmt.Sprintf("ip:%s", ip)
+	case "zoomeye":
+		if iputil.IsCIDR(ip) {
+			return fmt.Sprintf("cidr:%s", ip)
+		}
+		return fmt.Sprintf("ip:%s", ip)
+	case "censys":
+		return fmt.Sprintf("ip: %s", ip)
+	case "netlas":
+		return fmt.Sprintf("ip:%s", ip)
+	case "zone0":
+		return fmt.Sprintf("ip==%s", ip)
+	default:
+		return ip
+	}
+}
+
+func asnQuery(asn, engine string) string {
+	switch engine {
+	case "fofa":
+		return fmt.Sprintf("asn=\"%s\"", asn)
+	case "quake":
+		return fmt.Sprintf("asn:%s", asn)
+	case "hunter":
+		return fmt.Sprintf("as.number=\"%s\"", asn)
+	case "shodan":
+		return fmt.Sprintf("asn:AS%s", asn)
+	case "zoomeye":
+		return fmt.Sprintf("asn:%s", asn)
+	case "censys":
+		return fmt.Sprintf("autonomous_system.asn: %s", asn)
+	default:
+		return asn
+	}
+}
+
+// faviconHash returns the hash of a favicon target without its prefix and plus sign
+func faviconHash(target string) string {
+	return strings.TrimPrefix(faviconPrefixReg.ReplaceAllString(target, ""), "+")
+}
+
+// FaviconQuery builds the favicon search of engine, hash is either the
+// shodan style mmh3 hash or the md5 of the icon depending on the engine
+func FaviconQuery(hash, engine string) (string, bool) {
+	isMD5 := md5Reg.MatchString(hash)
+	switch strings.TrimSuffix(engine, "-spider") {
+	case "fofa":
+		if isMD5 {
+			return "", false
+		}
+		return fmt.Sprintf("icon_hash=\"%s\"", hash), true
+	case "shodan":
+		if isMD5 {
+			return "", false
+		}
+		return fmt.Sprintf("http.favicon.hash:%s", hash), true
+	case "zoomeye":
+		// zoomeye accepts both hashes, mmh3 only to avoid running the same search twice
+		if isMD5 {
+			return "", false
+		}
+		return fmt.Sprintf("iconhash:\"%s\"", hash), true
+	case "hunter":
+		if !isMD5 {
+			return "", false
+		}
+		return fmt.Sprintf("web.icon=\"%s\"", hash), true
+	case "quake":
+		if !isMD5 {
+			return "", false
+		}
+		return fmt.Sprintf("favicon:\"%s\"", hash), true
+	case "censys":
+		if !isMD5 {
+			return "", false
+		}
+		return fmt.Sprintf("services.http.response.favicons.md5_hash: %s", hash), true
+	default:
+		return "", false
+	}
+}
+
+// CertQuery builds the certificate fingerprint search of engine
+func CertQuery(fingerprint, engine string) (string, bool) {
+	isSHA256 := len(fingerprint) == 64
+	switch strings.TrimSuffix(engine, "-spider") {
+	case "fofa":
+		return fmt.Sprintf("cert=\"%s\"", fingerprint), true
+	case "shodan":
+		return fmt.Sprintf("ssl.cert.fingerprint:%s", fingerprint), true
+	case "quake":
+		return fmt.Sprintf("cert:\"%s\"", fingerprint), true
+	case "hunter":
+		if isSHA256 {
+			return fmt.Sprintf("cert.sha-256=\"%s\"", fingerprint), true
+		}
+		return fmt.Sprintf("cert.sha-1=\"%s\"", fingerprint), true
+	case "zoomeye":
+		return fmt.Sprintf("ssl:\"%s\"", fingerprint), true
+	case "censys":
+		if !isSHA256 {
+			return fmt.Sprintf("services.tls.certificates.leaf_data.fingerprint_sha1: %s", fingerprint), true
+		}
+		return fmt.Sprintf("services.tls.certificates.leaf_data.fingerprint: %s", fingerprint), true
+	default:
+		return "", false
+	}
+}
+
+// orgQuery builds the certificate subject organization search of engine
+func orgQuery(org, engine string) (string, bool) {
+	switch engine {
+	case "fofa":
+		return fmt.Sprintf("cert.subject.org=\"%s\"", org), true
+	case "hunter":
+		return fmt.Sprintf("cert.subject.org=\"%s\"", org), true
+	case "shodan":
+		return fmt.Sprintf("ssl.cert.subject.o:\"%s\"", org), true
+	case "quake":
+		return fmt.Sprintf("cert:\"%s\"", org), true
+	case "zoomeye":
+		return fmt.Sprintf("ssl:\"%s\"", org), true
+	case "censys":
+		return fmt.Sprintf("services.tls.certificates.leaf_data.subject.organization: \"%s\"", org), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,88 @@
+package sources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyTarget(t *testing.T) {
+	tests := map[string]TargetType{
+		"example.com":                      TargetDomain,
+		"1.1.1.1":                          TargetIP,
+		"2001:db8::1":                      TargetIP,
+		"10.0.0.0/24":                      TargetCIDR,
+		"AS13335":                          TargetASN,
+		"-1839349452":                      TargetFavicon,
+		"mmh3:116323821":                   TargetFavicon,
+		"favicon:+116323821":               TargetFavicon,
+		"13335":                            TargetRaw,
+		"f3418a443e7d841097c714d69ec4bcb8": TargetFavicon,
+		"1a8d6bd1e2bc4c0f06f55b8f8a83b8bb2ac5c4cf": TargetCert,
+		`title="login"`: TargetRaw,
+	}
+	for target, expected := range tests {
+		require.Equal(t, expected, ClassifyTarget(target), target)
+	}
+}
+
+// capableAgent searches the kinds of targets it is given
+type capableAgent struct {
+	fakeAgent
+	name         string
+	capabilities []TargetType
+}
+
+func (agent *capableAgent) Name() string {
+	return agent.name
+}
+
+func (agent *capableAgent) Capabilities() []TargetType {
+	return agent.capabilities
+}
+
+func TestTargetQuery(t *testing.T) {
+	agent := func(name string, capabilities ...TargetType) Agent {
+		return &capableAgent{name: name, capabilities: capabilities}
+	}
+	query, ok := TargetQuery("example.com", TargetDomain, agent("fofa", AllTargets...))
+	require.True(t, ok)
+	require.Equal(t, `domain="example.com"`, query)
+
+	query, ok = TargetQuery("10.0.0.0/24", TargetCIDR, agent("shodan", AllTargets...))
+	require.True(t, ok)
+	require.Equal(t, "net:10.0.0.0/24", query)
+
+	query, ok = TargetQuery("AS13335", TargetASN, agent("hunter", AllTargets...))
+	require.True(t, ok)
+	require.Equal(t, `as.number="13335"`, query)
+
+	query, ok = TargetQuery("mmh3:116323821", TargetFavicon, agent("fofa", AllTargets...))
+	require.True(t, ok)
+	require.Equal(t, `icon_hash="116323821"`, query)
+
+	query, ok = CertQuery(strings.Repeat("ab", 32), "hunter")
+	require.True(t, ok)
+	require.Equal(t, `cert.sha-256="`+strings.Repeat("ab", 32)+`"`, query)
+
+	query, ok = FaviconQuery("-1839349452", "zoomeye-spider")
+	require.True(t, ok)
+	require.Equal(t, `iconhash:"-1839349452"`, query)
+	_, ok = FaviconQuery("f3418a443e7d841097c714d69ec4bcb8", "fofa")
+	require.False(t, ok)
+
+	query, ok = TargetQuery("example.com", TargetDomain, agent("rapiddns-spider", TargetDomain))
+	require.True(t, ok)
+	require.Equal(t, "example.com", query)
+
+	_, ok = TargetQuery(`title="login"`, TargetRaw, agent("rapiddns-spider", TargetDomain))
+	require.False(t, ok)
+	_, ok = TargetQuery("example.com", TargetDomain, agent("shodan-idb", TargetIP, TargetCIDR))
+	require.False(t, ok)
+
+	// agents without capabilities only accept raw queries
+	require.Equal(t, []TargetType{TargetRaw}, CapabilitiesOf(&fakeAgent{}))
+	require.False(t, Supports(&fakeAgent{}, TargetDomain))
+	require.True(t, Supports(agent("plain", TargetDomain), TargetDomain))
+}
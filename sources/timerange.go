@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeRangeEngines filter results by -since/-until with their own parameters or query
+// syntax, the results of other engines are filtered on the last seen time of their raw response
+var TimeRangeEngines = []string{"hunter", "hunterhow", "fofa", "quake", "zoomeye", "shodan"}
+
+// DefaultOldestResult is before the oldest results of the engines, the time shards of
+// queries without -since don't go back further
+var DefaultOldestResult = time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SupportsTimeRange returns true when engine filters results by time itself
+func SupportsTimeRange(engine string) bool {
+	return contains(TimeRangeEngines, engine)
+}
+
+// lastSeenPaths are the dotted paths of the time a service was last seen in the raw
+// responses of each engine, the other engines have none in the raw results they keep
+var lastSeenPaths = map[string][]string{
+	"shodan": {"timestamp"},
+	"censys": {"last_updated_at"},
+	"fofa":   {"lastupdatetime"},
+}
+
+// defaultLastSeenPaths are the paths of the results of other engines and tools
+var defaultLastSeenPaths = []string{"last_seen", "last_updated_at", "updated_at", "lastupdatetime"}
+
+// lastSeenLayouts are the time layouts of the engines, unix timestamps are parsed too
+var lastSeenLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999", "2006-01-02 15:04:05", "2006-01-02"}
+
+// LastSeen returns the time result was last seen by its engine, false is returned when
+// its raw response has none
+func LastSeen(result Result) (time.Time, bool) {
+	if len(result.Raw) == 0 {
+		return time.Time{}, false
+	}
+	var raw interface{}
+	if err := json.Unmarshal(result.Raw, &raw); err != nil {
+		return time.Time{}, false
+	}
+	paths, ok := lastSeenPaths[result.Source]
+	if !ok {
+		paths = defaultLastSeenPaths
+	}
+	for _, path := range paths {
+		value := valueAt(raw, path)
+		if value == "" {
+			continue
+		}
+		if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+			// milliseconds are used by some engines
+			if unix > 1e12 {
+				return time.UnixMilli(unix), true
+			}
+			return time.Unix(unix, 0), true
+		}
+		for _, layout := range lastSeenLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// InTimeRange returns false when result was last seen outside of since and until, results
+// without a last seen time are kept. zero times are not bounds
+func InTimeRange(result Result, since, until time.Time) bool {
+	if since.IsZero() && until.IsZero() {
+		return true
+	}
+	lastSeen, ok := LastSeen(result)
+	if !ok {
+		return true
+	}
+	return (since.IsZero() || !lastSeen.Before(since)) && (until.IsZero() || !lastSeen.After(until))
+}
+
+// valueAt returns the string or number at the dotted path of value, empty when there is none
+func valueAt(value interface{}, path string) string {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastSeen(t *testing.T) {
+	lastSeen, ok := LastSeen(Result{Raw: []byte(`{"ip":"1.1.1.1","last_seen":"2026-03-01T10:00:00Z"}`)})
+	require.True(t, ok)
+	require.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), lastSeen.UTC())
+
+	lastSeen, ok = LastSeen(Result{Source: "fofa", Raw: []byte(`{"ip":"1.1.1.1","lastupdatetime":"2026-02-01 08:00:00"}`)})
+	require.True(t, ok)
+	require.Equal(t, 2026, lastSeen.Year())
+
+	lastSeen, ok = LastSeen(Result{Source: "shodan", Raw: []byte(`{"timestamp":"2026-02-01T08:00:00.123456","last_seen":"2020-01-01"}`)})
+	require.True(t, ok)
+	require.Equal(t, time.February, lastSeen.Month())
+
+	// keys nested in other fields are not last seen times
+	_, ok = LastSeen(Result{Raw: []byte(`{"ip":"1.1.1.1","http":{"time":"2020-01-01"},"data":{"last_seen":"2020-01-01"}}`)})
+	require.False(t, ok)
+
+	lastSeen, ok = LastSeen(Result{Raw: []byte(`{"updated_at":1767225600000}`)})
+	require.True(t, ok)
+	require.Equal(t, int64(1767225600), lastSeen.Unix())
+
+	_, ok = LastSeen(Result{Raw: []byte(`{"ip":"1.1.1.1"}`)})
+	require.False(t, ok)
+}
+
+func TestInTimeRange(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	inside := Result{Raw: []byte(`{"last_seen":"2026-01-15T00:00:00Z"}`)}
+	before := Result{Raw: []byte(`{"last_seen":"2025-12-15T00:00:00Z"}`)}
+	after := Result{Raw: []byte(`{"last_seen":"2026-03-15T00:00:00Z"}`)}
+
+	require.True(t, InTimeRange(inside, since, until))
+	require.False(t, InTimeRange(before, since, until))
+	require.False(t, InTimeRange(after, since, until))
+	require.True(t, InTimeRange(after, since, time.Time{}), "zero until is not a bound")
+	require.True(t, InTimeRange(Result{IP: "1.1.1.1"}, since, until), "results without last seen are kept")
+}
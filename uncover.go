@@ -21,6 +21,8 @@ import (
 	"github.com/wjlin0/uncover/sources/agent/zone0"
 	zoomeye_spider "github.com/wjlin0/uncover/sources/agent/zoomeye-spider"
 	"github.com/wjlin0/uncover/utils/strings"
+	"net/url"
+	"sort"
 	"sync"
 	"time"
 
@@ -43,16 +45,39 @@ import (
 
 var DefaultChannelBuffSize = 32
 
+// DefaultConcurrency is the number of queries processed at the same time
+var DefaultConcurrency = 10
+
 var DefaultCallback = func(query string, agent string) string {
 	return query
 }
 
 type Options struct {
-	Agents   []string // Uncover Agents to use
-	Queries  []string // Queries to pass to Agents
-	Limit    int
-	MaxRetry int
-	Timeout  int
+	Agents  []string // Uncover Agents to use
+	Queries []string // Queries to pass to Agents
+	// QueryStream lazily supplies queries after Queries are consumed,
+	// queries are pulled from it only when a worker is free
+	QueryStream <-chan string
+	// Targets are classified (domain, ip, cidr, asn, favicon or cert hash, raw query)
+	// and sent only to the agents supporting that kind, using their query syntax
+	Targets []string
+	// TargetStream lazily supplies targets after Targets are consumed
+	TargetStream <-chan string
+	// TypedTargets are targets whose kind is already known, like certificate pivots
+	TypedTargets []sources.Target
+	// Recursive feeds pivots found in results (apex domains, /24s, certificate
+	// fingerprints and orgs, favicon hashes) back as new targets up to Depth
+	Recursive bool
+	Depth     int
+	// PivotBudget is the maximum number of pivots queried per depth
+	PivotBudget int
+	// Scope restricts pivoting to these domains, ips and cidrs
+	Scope []string
+	// Concurrency is the number of queries executed at the same time
+	Concurrency int
+	Limit       int
+	MaxRetry    int
+	Timeout     int
 	// Note these ratelimits are used as fallback in case agent
 	// ratelimit is not available in DefaultRateLimits
 	RateLimit              uint          // default 30 req
@@ -60,6 +85,31 @@ type Options struct {
 	ProviderConfigLocation string
 	Proxy                  string
 	ProxyAuth              string
+
+	// Since and Until restrict results to the ones seen in this time range, with the
+	// filters of the engines or on the last seen time of results
+	Since time.Time
+	Until time.Time
+	// DisableSharding stops splitting queries over the result cap of engines into shards
+	DisableSharding bool
+	// EngineOptions are the engine specific options of each agent by name (-eo engine.key=value)
+	EngineOptions map[string]map[string]string
+
+	// ProxyList are proxies rotated over instead of Proxy, checked when the service is created
+	ProxyList []string
+	// ProxyCheckURL is requested through each proxy of ProxyList to check it is alive,
+	// sources.DefaultProxyCheckURL when empty
+	ProxyCheckURL string
+	// ProxyRotation is sources.RotateRequest (default) or sources.RotateAgent
+	ProxyRotation string
+	// ProxyRoutes are the sources.RouteDirect or sources.RoutePool routes of the engines
+	ProxyRoutes map[string]string
+	// CookieFiles are the cookie files of the web accounts of spider agents by name, used
+	// instead of the cookies of the provider config
+	CookieFiles map[string]string
+	// Progress records the results received by ExecuteWithCallback, the queries it
+	// finished in a previous run are skipped and the other ones resumed from their last page
+	Progress *Progress
 }
 
 // Service handler of all uncover Agents
@@ -69,6 +119,8 @@ type Service struct {
 	Session  *sources.Session
 	Provider *sources.Provider
 	Keys     sources.Keys
+
+	pivoter *pivoter
 }
 
 // New creates new uncover service instance
@@ -136,6 +188,10 @@ func New(opts *Options) (*Service, error) {
 	}
 	s.Provider = sources.NewProvider(opts.ProviderConfigLocation)
 	s.Keys = s.Provider.GetKeys()
+	s.useProviderOptions()
+	if err := s.validateEngineOptions(); err != nil {
+		return nil, err
+	}
 
 	if opts.RateLimit == 0 {
 		opts.RateLimit = 30
@@ -149,73 +205,365 @@ func New(opts *Options) (*Service, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(opts.ProxyList) > 0 {
+		if err := s.useProxyPool(); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.useEngineSettings(); err != nil {
+		return nil, err
+	}
+	if err := s.useCredentials(); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
+// useProviderOptions adds the default options of the provider config of the agents to
+// EngineOptions, options given in EngineOptions are kept
+func (s *Service) useProviderOptions() {
+	for _, agent := range s.Agents {
+		config := s.Provider.Engine(agent.Name())
+		if config == nil || len(config.Options) == 0 {
+			continue
+		}
+		if s.Options.EngineOptions == nil {
+			s.Options.EngineOptions = make(map[string]map[string]string)
+		}
+		options := s.Options.EngineOptions[agent.Name()]
+		if options == nil {
+			options = make(map[string]string)
+			s.Options.EngineOptions[agent.Name()] = options
+		}
+		for key, value := range config.Options {
+			if _, ok := options[key]; !ok {
+				options[key] = value
+			}
+		}
+	}
+}
+
+// useEngineSettings sends the requests of the agents with the url, proxy, rate limit
+// and timeout of their provider config
+func (s *Service) useEngineSettings() error {
+	for _, agent := range s.Agents {
+		config := s.Provider.Engine(agent.Name())
+		if config == nil {
+			continue
+		}
+		if err := s.Session.Configure(agent.Name(), config); err != nil {
+			return err
+		}
+		if config.URL != "" {
+			gologger.Verbose().Msgf("Using %s for %s\n", config.URL, agent.Name())
+		}
+	}
+	return nil
+}
+
+// useCredentials sends the cookies of the web accounts of the provider config and
+// CookieFiles with the requests of their agents
+func (s *Service) useCredentials() error {
+	cookies := make(map[string]string)
+	for agent, value := range s.Provider.Cookies {
+		if _, ok := s.Options.CookieFiles[agent]; !ok {
+			cookies[agent] = value
+		}
+	}
+	for agent, file := range s.Options.CookieFiles {
+		cookies[agent] = file
+	}
+	for agent, value := range cookies {
+		if value == "" || !sliceutil.Contains(s.Options.Agents, agent) {
+			continue
+		}
+		credentials, err := sources.ReadCredentials(value)
+		if err != nil {
+			return errorutil.NewWithErr(err).Msgf("could not read %s cookies", agent)
+		}
+		if err := s.Session.SetCredentials(agent, credentials); err != nil {
+			return err
+		}
+		gologger.Verbose().Msgf("Using the web account cookies of %s\n", agent)
+	}
+	return nil
+}
+
+// useProxyPool routes the requests of the session through the alive proxies of ProxyList
+func (s *Service) useProxyPool() error {
+	proxies := make([]*url.URL, 0, len(s.Options.ProxyList))
+	for _, value := range s.Options.ProxyList {
+		proxyURL, err := sources.ParseProxy(value, s.Options.ProxyAuth)
+		if err != nil {
+			return err
+		}
+		proxies = append(proxies, proxyURL)
+	}
+	pool := sources.NewProxyPool(proxies)
+	if s.Options.ProxyRotation != "" {
+		pool.Rotation = s.Options.ProxyRotation
+	}
+	pool.Routes = s.Options.ProxyRoutes
+	checkURL := s.Options.ProxyCheckURL
+	if checkURL == "" {
+		checkURL = sources.DefaultProxyCheckURL
+	}
+	timeout := time.Duration(s.Options.Timeout) * time.Second
+	alive := pool.Check(context.Background(), checkURL, timeout)
+	if alive == 0 {
+		return errorutil.NewWithTag("uncover", "no proxy of the proxy list is alive")
+	}
+	gologger.Verbose().Msgf("%d of %d proxies are alive\n", alive, len(proxies))
+	s.Session.UseProxyPool(pool)
+	return nil
+}
+
 func (s *Service) Execute(ctx context.Context) (<-chan sources.Result, error) {
+	if err := s.checkAgents(); err != nil {
+		return nil, err
+	}
 
+	megaChan := make(chan sources.Result, DefaultChannelBuffSize)
+	inputs := s.inputStream(ctx)
+	if s.Options.Recursive {
+		s.pivoter = newPivoter(s.Options, s.Agents)
+		inputs = s.pivoter.schedule(ctx, inputs)
+	}
+	concurrency := s.Options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	// workers pull the next query only after every agent finished the current one
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for in := range inputs {
+				s.executeQuery(ctx, in, megaChan)
+				if s.pivoter != nil {
+					s.pivoter.finish(ctx)
+				}
+			}
+		}()
+	}
+
+	// close channel when all sources return
+	go func(wg *sync.WaitGroup, megaChan chan sources.Result) {
+		wg.Wait()
+		defer close(megaChan)
+	}(wg, megaChan)
+
+	return megaChan, nil
+}
+
+// checkAgents returns an error when none of the agents can be used
+func (s *Service) checkAgents() error {
 	// unlikely but as a precaution to handle random panics check all types
 	if err := s.nilCheck(); err != nil {
-		return nil, err
+		return err
 	}
 	switch {
 	case len(s.Agents) == 0:
-		return nil, errorutil.NewWithTag("uncover", "no agent/source specified")
+		return errorutil.NewWithTag("uncover", "no agent/source specified")
 	case !s.hasAnyAnonymousProvider() && !s.Provider.HasKeys():
-		return nil, errorutil.NewWithTag("uncover", "agents %v requires keys but no keys were found. please read docs %s on how to add keys", s.Options.Agents, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration")
+		return errorutil.NewWithTag("uncover", "agents %v requires keys but no keys were found. please read docs %s on how to add keys", s.Options.Agents, "https://github.com/wjlin0/uncover?tab=readme-ov-file#provider-configuration")
 	case s.hasOnlyDestructAgent():
-		return nil, errorutil.NewWithTag("uncover", "destructive agents %v cannot be used with uncover. please use command show to see destruct agents (-da)", s.Options.Agents)
+		return errorutil.NewWithTag("uncover", "destructive agents %v cannot be used with uncover. please use command show to see destruct agents (-da)", s.Options.Agents)
 	}
+	return nil
+}
 
-	megaChan := make(chan sources.Result, DefaultChannelBuffSize)
-	// iterate and run all sources
-	wg := &sync.WaitGroup{}
-	for _, q := range s.Options.Queries {
-	agentLabel:
-		for _, agent := range s.Agents {
-			if strings.Contains(DestructAgents(), agent.Name()) {
-				gologger.Warning().Msgf("destructive agent %s cannot be used with uncover", agent.Name())
-				continue agentLabel
-			}
-			keys := s.Provider.GetKeys()
-			if keys.Empty() && !(stringsutil.EqualFoldAny(agent.Name(), AnonymousAgents()...)) {
-				gologger.Error().Msgf("%s agent given but keys not found", agent.Name())
-				continue agentLabel
+// input is a single query or target waiting for a free worker
+type input struct {
+	value string
+	// target inputs are classified and only sent to capable agents
+	target     bool
+	targetType sources.TargetType
+	// depth and pivot are set on inputs found by recursive pivoting
+	depth int
+	pivot *sources.Pivot
+}
+
+// inputStream merges static and lazily streamed queries and targets
+func (s *Service) inputStream(ctx context.Context) <-chan input {
+	inputs := make(chan input)
+	go func() {
+		defer close(inputs)
+		send := func(values []string, stream <-chan string, target bool) bool {
+			for _, value := range values {
+				select {
+				case <-ctx.Done():
+					return false
+				case inputs <- input{value: value, target: target}:
+				}
 			}
-			ch, err := agent.Query(s.Session, &sources.Query{
-				Query: DefaultCallback(q, agent.Name()),
-				Limit: s.Options.Limit,
-			})
-			if err != nil {
-				gologger.Error().Msgf("%s\n", err)
-				continue agentLabel
+			if stream == nil {
+				return true
 			}
-			wg.Add(1)
-			go func(source, relay chan sources.Result, ctx context.Context) {
-				defer wg.Done()
-				for {
+			for {
+				select {
+				case <-ctx.Done():
+					return false
+				case value, ok := <-stream:
+					if !ok {
+						return true
+					}
+					if value == "" {
+						continue
+					}
 					select {
 					case <-ctx.Done():
+						return false
+					case inputs <- input{value: value, target: target}:
+					}
+				}
+			}
+		}
+		if !send(s.Options.Queries, s.Options.QueryStream, false) {
+			return
+		}
+		for _, target := range s.Options.TypedTargets {
+			select {
+			case <-ctx.Done():
+				return
+			case inputs <- input{value: target.Value, target: true, targetType: target.Type}:
+			}
+		}
+		send(s.Options.Targets, s.Options.TargetStream, true)
+	}()
+	return inputs
+}
+
+// executeQuery runs a single input against all agents and blocks until all of them return
+func (s *Service) executeQuery(ctx context.Context, in input, megaChan chan sources.Result) {
+	targetType := in.targetType
+	if in.target && targetType == "" {
+		targetType = sources.ClassifyTarget(in.value)
+	}
+	wg := &sync.WaitGroup{}
+agentLabel:
+	for _, agent := range s.Agents {
+		q, ok := s.agentQuery(agent, in, targetType)
+		if !ok {
+			continue agentLabel
+		}
+		progress := s.Options.Progress
+		if progress.Done(agent.Name(), q) {
+			gologger.Verbose().Msgf("Skipping %s query %s finished by the previous run\n", agent.Name(), q)
+			continue agentLabel
+		}
+		query := s.newQuery(agent, q)
+		progress.resume(agent, query)
+		ch, err := s.queryAgent(ctx, agent, query)
+		if err != nil {
+			gologger.Error().Msgf("%s\n", err)
+			continue agentLabel
+		}
+		wg.Add(1)
+		filter := !sources.SupportsTimeRange(agent.Name())
+		go func(name, query string, source, relay chan sources.Result, ctx context.Context) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case res, ok := <-source:
+					res.Timestamp = time.Now().Unix()
+					if !ok {
+						progress.Finish(name, query)
 						return
-					case res, ok := <-source:
-						res.Timestamp = time.Now().Unix()
-						if !ok {
-							return
-						}
-						relay <- res
+					}
+					if filter && res.Error == nil && !sources.InTimeRange(res, s.Options.Since, s.Options.Until) {
+						continue
+					}
+					res.Query = query
+					res.Pivot = in.pivot
+					if res.Error == nil {
+						progress.Sent(name, query)
+					}
+					relay <- res
+					if s.pivoter != nil && res.Error == nil {
+						s.pivoter.pivot(ctx, res, in)
 					}
 				}
-			}(ch, megaChan, ctx)
+			}
+		}(agent.Name(), q, ch, megaChan, ctx)
+	}
+	wg.Wait()
+}
+
+// newQuery returns the query sent to agent with the limit, time range and engine options of the service
+// and the page size of the provider config
+func (s *Service) newQuery(agent sources.Agent, q string) *sources.Query {
+	query := &sources.Query{
+		Query:   q,
+		Limit:   s.Options.Limit,
+		Since:   s.Options.Since,
+		Until:   s.Options.Until,
+		Options: s.Options.EngineOptions[agent.Name()],
+	}
+	if config := s.Provider.Engine(agent.Name()); config != nil {
+		query.PageSize = config.PageSize
+	}
+	return query
+}
+
+// validateEngineOptions returns an error when engine options are given for an agent
+// which is not used or are not in its schema
+func (s *Service) validateEngineOptions() error {
+	engines := make([]string, 0, len(s.Options.EngineOptions))
+	for engine := range s.Options.EngineOptions {
+		engines = append(engines, engine)
+	}
+	sort.Strings(engines)
+	for _, engine := range engines {
+		var agent sources.Agent
+		for _, a := range s.Agents {
+			if a.Name() == engine {
+				agent = a
+				break
+			}
+		}
+		if agent == nil {
+			return errorutil.NewWithTag("uncover", "engine options given for %s which is not used, add it with -e %s", engine, engine)
+		}
+		if err := sources.ValidateOptions(agent, s.Options.EngineOptions[engine]); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// close channel when all sources return
-	go func(wg *sync.WaitGroup, megaChan chan sources.Result) {
-		wg.Wait()
-		defer close(megaChan)
-	}(wg, megaChan)
+// agentQuery returns the query of in for agent, false is returned when agent
+// can't be used or doesn't support the kind of target of in
+func (s *Service) agentQuery(agent sources.Agent, in input, targetType sources.TargetType) (string, bool) {
+	if strings.Contains(DestructAgents(), agent.Name()) {
+		gologger.Warning().Msgf("destructive agent %s cannot be used with uncover", agent.Name())
+		return "", false
+	}
+	keys := s.Provider.GetKeys()
+	if keys.Empty() && !(stringsutil.EqualFoldAny(agent.Name(), AnonymousAgents()...)) {
+		gologger.Error().Msgf("%s agent given but keys not found", agent.Name())
+		return "", false
+	}
+	q := DefaultCallback(in.value, agent.Name())
+	if in.target {
+		var ok bool
+		if q, ok = sources.TargetQuery(in.value, targetType, agent); !ok {
+			gologger.Verbose().Msgf("%s agent does not support %s target %s\n", agent.Name(), targetType, in.value)
+			return "", false
+		}
+	}
+	return q, true
+}
 
-	return megaChan, nil
+// Pivots returns the pivots queried by a recursive execution
+func (s *Service) Pivots() []sources.Pivot {
+	if s.pivoter == nil {
+		return nil
+	}
+	return s.pivoter.Pivots()
 }
 
 // ExecuteWithCallback ExecuteWithWriters writes output to writer along with stdout
@@ -236,6 +584,9 @@ func (s *Service) ExecuteWithCallback(ctx context.Context, callback func(result
 				return nil
 			}
 			callback(result)
+			if result.Error == nil {
+				s.Options.Progress.Add(result.Source, result.Query)
+			}
 		}
 	}
 }
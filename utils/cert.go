@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// CertInfo holds the pivotable fields of a certificate
+type CertInfo struct {
+	SHA1         string
+	SHA256       string
+	Serial       string
+	CommonName   string
+	SANs         []string
+	Organization []string
+}
+
+// NewCertInfo extracts the fingerprints and subject of cert
+func NewCertInfo(cert *x509.Certificate) *CertInfo {
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+	info := &CertInfo{
+		SHA1:         hex.EncodeToString(sha1Sum[:]),
+		SHA256:       hex.EncodeToString(sha256Sum[:]),
+		Serial:       fmt.Sprintf("%x", cert.SerialNumber),
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+	}
+	info.SANs = append(info.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	return info
+}
+
+// ParseCertificatePEM returns the first certificate of a PEM encoded block list
+func ParseCertificatePEM(data []byte) (*CertInfo, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no certificate found in pem data")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewCertInfo(cert), nil
+	}
+}
+
+// FetchCertificate returns the leaf certificate served at address (host:port)
+func FetchCertificate(address string, timeout time.Duration) (*CertInfo, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{InsecureSkipVerify: true}
+	if net.ParseIP(host) == nil {
+		config.ServerName = host
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", address, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate served by %s", address)
+	}
+	return NewCertInfo(certs[0]), nil
+}
+
+// Domains returns the domain names of the common name and SANs without wildcards
+func (info *CertInfo) Domains() []string {
+	var domains []string
+	for _, name := range append([]string{info.CommonName}, info.SANs...) {
+		name = strings.ToLower(strings.TrimPrefix(name, "*."))
+		if IsValidDomain(name) {
+			domains = append(domains, name)
+		}
+	}
+	return RemoveDuplicateStrings(domains)
+}
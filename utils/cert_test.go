@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCertificatePEM(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(4660),
+		Subject:      pkix.Name{CommonName: "www.example.com", Organization: []string{"Example Org"}},
+		DNSNames:     []string{"*.example.com", "api.example.org"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	info, err := ParseCertificatePEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	require.Nil(t, err)
+	require.Len(t, info.SHA1, 40)
+	require.Len(t, info.SHA256, 64)
+	require.Equal(t, "1234", info.Serial)
+	require.Equal(t, []string{"Example Org"}, info.Organization)
+	require.Equal(t, []string{"www.example.com", "example.com", "api.example.org"}, info.Domains())
+
+	_, err = ParseCertificatePEM([]byte("not a certificate"))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// FaviconHash returns the shodan style favicon hash, the mmh3 hash of
+// the base64 encoded icon wrapped every 76 characters like python's base64.encodebytes
+func FaviconHash(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	buffer := bytes.Buffer{}
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		buffer.WriteString(encoded[i:end])
+		buffer.WriteByte('\n')
+	}
+	return int32(Murmur3(buffer.Bytes(), 0))
+}
+
+// FaviconHashes returns the mmh3 and md5 hash of a favicon
+func FaviconHashes(data []byte) (string, string, error) {
+	md5Hash, err := GetHash(data, "md5")
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprint(FaviconHash(data)), string(md5Hash), nil
+}
+
+// Murmur3 is the 32-bit x86 murmur3 hash
+func Murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+	h := seed
+	length := len(data)
+	blocks := length / 4
+	for i := 0; i < blocks; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[blocks*4:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(length)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMurmur3(t *testing.T) {
+	require.Equal(t, int32(613153351), int32(Murmur3([]byte("hello"), 0)))
+	require.Equal(t, int32(-156908512), int32(Murmur3([]byte("foo"), 0)))
+	require.Equal(t, uint32(0), Murmur3(nil, 0))
+}
+
+func TestFaviconHashes(t *testing.T) {
+	mmh3, md5, err := FaviconHashes([]byte("hello"))
+	require.Nil(t, err)
+	// python: mmh3.hash(codecs.encode(b"hello", "base64"))
+	require.Equal(t, "1155597304", mmh3)
+	require.Equal(t, "5d41402abc4b2a76b9719d911017c592", md5)
+}